@@ -0,0 +1,73 @@
+package allmusic
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ynori7/music/cache"
+	"github.com/ynori7/music/config"
+)
+
+// CachingClient serves allmusic pages from the response cache when possible and otherwise
+// fetches them and stores them in the cache. Pages are keyed by their URL.
+type CachingClient struct {
+	httpClient HttpClient
+	cache      *cache.Cache
+	ttl        config.CacheTTL
+	refresh    bool
+}
+
+// NewCachingClient wraps the given client with the cache. When refresh is true, cached pages
+// are ignored but the cache is still updated with the new responses.
+func NewCachingClient(httpClient HttpClient, responseCache *cache.Cache, ttl config.CacheTTL, refresh bool) CachingClient {
+	return CachingClient{
+		httpClient: httpClient,
+		cache:      responseCache,
+		ttl:        ttl,
+		refresh:    refresh,
+	}
+}
+
+func (c CachingClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return c.httpClient.Do(req)
+	}
+
+	key := req.URL.String()
+	if !c.refresh {
+		if body, ok := c.cache.Get(key, c.ttlFor(req.URL)); ok {
+			return newResponse(req, body), nil
+		}
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		return res, err //only successful responses are cached
+	}
+
+	body, err := bufferBody(res)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cache.Set(key, body); err != nil {
+		//the page was fetched successfully, so the run can go on without the cache
+		log.WithFields(log.Fields{"Logger": "CachingClient", "Url": key, "error": err}).Warn("Error caching response")
+	}
+
+	return res, nil
+}
+
+// ttlFor determines how long the page may be cached based on which kind of allmusic page it is
+func (c CachingClient) ttlFor(u *url.URL) time.Duration {
+	switch {
+	case strings.Contains(u.Path, "/newreleases"):
+		return c.ttl.NewReleases
+	case strings.HasSuffix(u.Path, "/discographyAjax"):
+		return c.ttl.Discography
+	default:
+		return c.ttl.Artist
+	}
+}
@@ -0,0 +1,120 @@
+package allmusic
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hulkhttp "github.com/ynori7/hulksmash/http"
+	"github.com/ynori7/music/cache"
+	"github.com/ynori7/music/config"
+)
+
+func Test_CachingClient(t *testing.T) {
+	//given
+	reqCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		reqCount++
+		if req.URL.Path == "/artist/missing" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rw.Write([]byte("page " + req.URL.Path))
+	}))
+	defer server.Close()
+
+	responseCache, err := cache.New(t.TempDir())
+	require.NoError(t, err, "There was an error creating the cache")
+
+	ttl := config.CacheTTL{NewReleases: time.Hour, Artist: time.Hour, Discography: time.Hour}
+	httpClient := hulkhttp.NewClientV2ForTests(server.Client().Transport)
+
+	get := func(c CachingClient, path string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		res, err := c.Do(req)
+		require.NoError(t, err, "There was an error performing the request")
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	//when
+	cachingClient := NewCachingClient(httpClient, responseCache, ttl, false)
+	_, first := get(cachingClient, "/artist/king-diamond")
+	_, second := get(cachingClient, "/artist/king-diamond")
+	missingStatus, _ := get(cachingClient, "/artist/missing")
+	get(cachingClient, "/artist/missing")
+
+	//then
+	assert.Equal(t, "page /artist/king-diamond", first)
+	assert.Equal(t, first, second, "The cached page should be returned")
+	assert.Equal(t, http.StatusNotFound, missingStatus)
+	assert.Equal(t, 3, reqCount, "Only the unsuccessful response should be requested again")
+
+	//when refreshing
+	refreshingClient := NewCachingClient(httpClient, responseCache, ttl, true)
+	get(refreshingClient, "/artist/king-diamond")
+
+	//then
+	assert.Equal(t, 4, reqCount, "The cached page should be ignored when refreshing")
+}
+
+func Test_CachingClient_WriteError(t *testing.T) {
+	//given
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("page " + req.URL.Path))
+	}))
+	defer server.Close()
+
+	directory := filepath.Join(t.TempDir(), "cache")
+	responseCache, err := cache.New(directory)
+	require.NoError(t, err, "There was an error creating the cache")
+	require.NoError(t, os.RemoveAll(directory), "The cache directory should be gone so that writing fails")
+
+	ttl := config.CacheTTL{NewReleases: time.Hour, Artist: time.Hour, Discography: time.Hour}
+	cachingClient := NewCachingClient(hulkhttp.NewClientV2ForTests(server.Client().Transport), responseCache, ttl, false)
+
+	//when
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/artist/king-diamond", nil)
+	res, err := cachingClient.Do(req)
+
+	//then
+	require.NoError(t, err, "The response should be returned even though it couldn't be cached")
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "page /artist/king-diamond", string(body))
+}
+
+func Test_CachingClient_ttlFor(t *testing.T) {
+	ttl := config.CacheTTL{NewReleases: time.Hour, Artist: 2 * time.Hour, Discography: 3 * time.Hour}
+	cachingClient := NewCachingClient(nil, nil, ttl, false)
+
+	testcases := map[string]struct {
+		Url      string
+		Expected time.Duration
+	}{
+		"New releases": {
+			Url:      GetNewReleasesUrlForWeek(mustParseReleaseWeek(t, "20200327")),
+			Expected: time.Hour,
+		},
+		"Artist": {
+			Url:      BaseUrl + "/artist/king-diamond-mn0000040394",
+			Expected: 2 * time.Hour,
+		},
+		"Discography": {
+			Url:      BaseUrl + "/artist/king-diamond-mn0000040394/discographyAjax",
+			Expected: 3 * time.Hour,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		req, _ := http.NewRequest(http.MethodGet, testdata.Url, nil)
+		assert.Equal(t, testdata.Expected, cachingClient.ttlFor(req.URL), testcase)
+	}
+}
@@ -1,29 +1,45 @@
 package allmusic
 
+import "context"
+
 type Artist struct {
-	Name   string
-	Genres []string
-	Link   string
+	Name   string   `json:"name"`
+	Genres []string `json:"genres"`
+	Link   string   `json:"link"`
 }
 
 type Album struct {
-	Title  string
-	Link   string
-	Rating int //Out of 10. A zero means there is no rating
-	Image  string
-	Year   string
+	Title  string `json:"title"`
+	Link   string `json:"link"`
+	Rating int    `json:"rating"` //Out of 10. A zero means there is no rating
+	Image  string `json:"image"`
+	Year   string `json:"year"`
 }
 
 type Discography struct {
-	Artist        Artist
-	Albums        []Album
-	AverageRating int
-	BestRating    int
-	NewestRelease Album
-	Score         int //This is a score based on the various ratings available
+	Artist        Artist   `json:"artist"`
+	Albums        []Album  `json:"albums"`
+	AverageRating int      `json:"average_rating"`
+	BestRating    int      `json:"best_rating"`
+	NewestRelease Album    `json:"newest_release"`
+	Score         int      `json:"score"`         //This is a score from 0 to 100 based on the various ratings available
+	Watchlisted   bool     `json:"watchlisted"`   //The artist is one of the configured favourites which are always included
+	MatchedRules  []string `json:"matched_rules"` //The reasons why the release is interesting, e.g. "sub_genre: Heavy Metal"
 }
 
 type NewRelease struct {
-	ArtistLink    string
-	NewAlbumTitle string
+	ArtistName    string   `json:"artist_name"`
+	ArtistLink    string   `json:"artist_link"`
+	NewAlbumTitle string   `json:"new_album_title"`
+	Genres        []string `json:"genres"`
+}
+
+// NewReleaseSource provides all new releases of a release week
+type NewReleaseSource interface {
+	GetNewReleasesForWeek(ctx context.Context, week ReleaseWeek) ([]NewRelease, error)
+}
+
+// DiscographyProvider looks up the discography of an artist
+type DiscographyProvider interface {
+	GetArtistDiscography(ctx context.Context, link string) (*Discography, error)
 }
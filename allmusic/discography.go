@@ -1,6 +1,7 @@
 package allmusic
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
@@ -11,31 +12,33 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/ynori7/hulksmash/anonymizer"
-	hulkhttp "github.com/ynori7/hulksmash/http"
+	"github.com/ynori7/music/config"
 )
 
 const BaseUrl = "https://www.allmusic.com"
 
 type DiscographyClient struct {
-	httpClient    *hulkhttp.ClientV2
+	httpClient    HttpClient
 	reqAnonymizer anonymizer.Anonymizer
+	scorer        Scorer
 }
 
-func NewDiscographyClient() DiscographyClient {
+func NewDiscographyClient(httpClient HttpClient, scoring config.Scoring) DiscographyClient {
 	return DiscographyClient{
-		httpClient:    hulkhttp.NewClientV2(),
+		httpClient:    httpClient,
+		scorer:        NewScorer(scoring),
 		reqAnonymizer: anonymizer.New(int64(rand.Int())),
 	}
 }
 
-func (dc DiscographyClient) GetArtistDiscography(link string) (*Discography, error) {
-	discography, err := dc.lookupBasicInfo(link)
+func (dc DiscographyClient) GetArtistDiscography(ctx context.Context, link string) (*Discography, error) {
+	discography, err := dc.lookupBasicInfo(ctx, link)
 	if err != nil {
 		return nil, err
 	}
 
 	// Request the discography
-	req, err := http.NewRequest(http.MethodGet, link+"/discographyAjax", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link+"/discographyAjax", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -110,14 +113,14 @@ func (dc DiscographyClient) GetArtistDiscography(link string) (*Discography, err
 	if ratingCount > 0 {
 		discography.AverageRating = getAverage(ratingSum, ratingCount)
 	}
-	discography.Score = calculateScore(discography.BestRating, discography.AverageRating, ratingCount, discography.NewestRelease.Rating)
+	discography.Score = dc.scorer.Score(*discography)
 
 	return discography, nil
 }
 
-func (dc DiscographyClient) lookupBasicInfo(link string) (*Discography, error) {
+func (dc DiscographyClient) lookupBasicInfo(ctx context.Context, link string) (*Discography, error) {
 	// Request the HTML page.
-	req, err := http.NewRequest(http.MethodGet, link, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +156,17 @@ func (dc DiscographyClient) lookupBasicInfo(link string) (*Discography, error) {
 	return discography, nil
 }
 
+// RatedAlbums returns the number of albums which have a rating
+func (d Discography) RatedAlbums() int {
+	count := 0
+	for _, album := range d.Albums {
+		if album.Rating != 0 {
+			count++
+		}
+	}
+	return count
+}
+
 func getEditorRating(s *goquery.Selection) int {
 	ratingVal, _ := s.Attr("data-text")
 	ratingInt := 0
@@ -165,29 +179,6 @@ func getEditorRating(s *goquery.Selection) int {
 	return ratingInt
 }
 
-func calculateScore(bestRating, averageRating, ratingCount, newestAlbumRating int) int {
-	score := bestRating * 4    //40% of the score is from the best rating (gives some extra weight to the average)
-	score += averageRating * 4 //another 40% is from the average rating
-
-	if newestAlbumRating >= 8 {
-		score = newestAlbumRating * 8 //if the newest album is a well-rated one, use that as the base for the score to give it extra boost
-	}
-
-	//add a little extra weight based on the total number of ratings there were
-	switch {
-	case ratingCount > 7:
-		score += 20
-	case ratingCount > 5:
-		score += 15
-	case ratingCount > 2:
-		score += 10
-	case ratingCount > 1:
-		score += 5
-	}
-
-	return score
-}
-
 func getAverage(sum, count int) int {
 	return int(math.RoundToEven(float64(sum) / float64(count)))
 }
@@ -1,6 +1,7 @@
 package allmusic
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/ynori7/hulksmash/anonymizer"
 	hulkhttp "github.com/ynori7/hulksmash/http"
+	"github.com/ynori7/music/config"
 )
 
 func Test_GetArtistDiscography_KingDiamond(t *testing.T) {
@@ -38,7 +40,7 @@ func Test_GetArtistDiscography_KingDiamond(t *testing.T) {
 	}
 
 	//when
-	discography, err := dicographyClient.GetArtistDiscography(server.URL)
+	discography, err := dicographyClient.GetArtistDiscography(context.Background(), server.URL)
 
 	//then
 	require.NoError(t, err, "There was an error getting the discography")
@@ -172,8 +174,9 @@ func Test_calculateScore(t *testing.T) {
 		},
 	}
 
+	scorer := NewScorer(config.DefaultScoring())
 	for testdescription, testcase := range testdata {
-		score := calculateScore(testcase.bestRating, testcase.averageRating, testcase.ratingCount, testcase.newestAlbumRating)
+		score := scorer.calculate(float64(testcase.bestRating), float64(testcase.averageRating), testcase.ratingCount, testcase.newestAlbumRating)
 		if score != testcase.expectedScore {
 			t.Errorf("%s: expected %d but got %d", testdescription, testcase.expectedScore, score)
 		}
@@ -0,0 +1,102 @@
+package allmusic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RecordingClient saves the body of every successful response into a fixture directory so that
+// the run can be reproduced later with the ReplayClient.
+type RecordingClient struct {
+	httpClient HttpClient
+	directory  string
+}
+
+// NewRecordingClient wraps the given client and records the responses in the directory, creating it if necessary.
+func NewRecordingClient(httpClient HttpClient, directory string) (RecordingClient, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return RecordingClient{}, err
+	}
+	return RecordingClient{
+		httpClient: httpClient,
+		directory:  directory,
+	}, nil
+}
+
+func (c RecordingClient) Do(req *http.Request) (*http.Response, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		return res, err
+	}
+
+	body, err := bufferBody(res)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(c.directory, fixtureName(req.URL)), body, 0644); err != nil {
+		return nil, fmt.Errorf("error recording response: %w", err)
+	}
+
+	return res, nil
+}
+
+// ReplayClient serves the responses previously saved by the RecordingClient instead of making any requests.
+type ReplayClient struct {
+	directory string
+}
+
+func NewReplayClient(directory string) ReplayClient {
+	return ReplayClient{
+		directory: directory,
+	}
+}
+
+func (c ReplayClient) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	body, err := os.ReadFile(filepath.Join(c.directory, fixtureName(req.URL)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded response for %s", req.URL)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return newResponse(req, body), nil
+}
+
+var unsafeFixtureChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// fixtureName maps the URL to a readable file name, e.g. /artist/king-diamond-mn0000770007/discographyAjax
+// becomes artist_king-diamond-mn0000770007_discographyAjax.html
+func fixtureName(u *url.URL) string {
+	name := strings.Trim(u.Path, "/")
+	if u.RawQuery != "" {
+		name += "_" + u.RawQuery
+	}
+	if name == "" {
+		name = "index"
+	}
+	return unsafeFixtureChars.ReplaceAllString(name, "_") + ".html"
+}
+
+// bufferBody reads the whole response body and replaces it so that it can still be read by the caller
+func bufferBody(res *http.Response) ([]byte, error) {
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
@@ -0,0 +1,80 @@
+package allmusic
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hulkhttp "github.com/ynori7/hulksmash/http"
+)
+
+func Test_RecordAndReplay(t *testing.T) {
+	//given
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("page " + req.URL.Path))
+	}))
+	defer server.Close()
+
+	directory := t.TempDir()
+	recordingClient, err := NewRecordingClient(hulkhttp.NewClientV2ForTests(server.Client().Transport), directory)
+	require.NoError(t, err, "There was an error creating the recording client")
+
+	get := func(c HttpClient, path string) (string, error) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		res, err := c.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return string(body), nil
+	}
+
+	//when
+	recorded, err := get(recordingClient, "/artist/king-diamond")
+	require.NoError(t, err, "There was an error recording the response")
+	server.Close() //the replay must not need the server
+
+	replayed, err := get(NewReplayClient(directory), "/artist/king-diamond")
+	require.NoError(t, err, "There was an error replaying the response")
+	_, missingErr := get(NewReplayClient(directory), "/artist/mercyful-fate")
+
+	//then
+	assert.Equal(t, "page /artist/king-diamond", recorded)
+	assert.Equal(t, recorded, replayed)
+	assert.Error(t, missingErr, "An unrecorded response should not be replayed")
+}
+
+func Test_fixtureName(t *testing.T) {
+	testcases := map[string]struct {
+		Url      string
+		Expected string
+	}{
+		"New releases": {
+			Url:      "https://www.allmusic.com/newreleases/all/20200327",
+			Expected: "newreleases_all_20200327.html",
+		},
+		"Discography": {
+			Url:      "https://www.allmusic.com/artist/king-diamond-mn0000770007/discographyAjax",
+			Expected: "artist_king-diamond-mn0000770007_discographyAjax.html",
+		},
+		"Query": {
+			Url:      "https://www.allmusic.com/search?term=king+diamond",
+			Expected: "search_term_king_diamond.html",
+		},
+		"Root": {
+			Url:      "http://127.0.0.1:1234",
+			Expected: "index.html",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		u, err := url.Parse(testdata.Url)
+		require.NoError(t, err, testcase)
+		assert.Equal(t, testdata.Expected, fixtureName(u), testcase)
+	}
+}
@@ -0,0 +1,112 @@
+package allmusic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	hulkhttp "github.com/ynori7/hulksmash/http"
+	"github.com/ynori7/music/cache"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/retry"
+)
+
+// HttpClient performs the requests to allmusic. It is satisfied by hulkhttp.ClientV2 and allows
+// additional behavior, such as caching, to be layered on top of it.
+type HttpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// NewHttpClient builds the client which should be shared by the allmusic clients based on the configuration.
+func NewHttpClient(conf config.Config, cli config.CliConfig) (HttpClient, error) {
+	if cli.ReplayDirectory != "" {
+		if cli.RecordDirectory != "" {
+			return nil, fmt.Errorf("responses can't be recorded while replaying them")
+		}
+		return NewReplayClient(cli.ReplayDirectory), nil
+	}
+
+	var httpClient HttpClient = hulkhttp.NewClientV2()
+
+	if conf.Timeouts.Request > 0 {
+		httpClient = NewTimeoutClient(httpClient, conf.Timeouts.Request)
+	}
+	if conf.RateLimit.RequestsPerSecond > 0 {
+		httpClient = NewRateLimitedClient(httpClient, conf.RateLimit)
+	}
+	if conf.Retry.MaxAttempts > 1 {
+		httpClient = retry.NewClient(httpClient, conf.Retry)
+	}
+
+	if conf.Cache.Enabled && !cli.NoCache {
+		responseCache, err := cache.New(conf.Cache.Directory)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = NewCachingClient(httpClient, responseCache, conf.Cache.TTL, cli.RefreshCache)
+	}
+
+	if cli.RecordDirectory != "" {
+		recordingClient, err := NewRecordingClient(httpClient, cli.RecordDirectory)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = recordingClient
+	}
+
+	return httpClient, nil
+}
+
+// TimeoutClient limits how long each request may take, including reading the response body
+type TimeoutClient struct {
+	httpClient HttpClient
+	timeout    time.Duration
+}
+
+func NewTimeoutClient(httpClient HttpClient, timeout time.Duration) TimeoutClient {
+	return TimeoutClient{
+		httpClient: httpClient,
+		timeout:    timeout,
+	}
+}
+
+func (c TimeoutClient) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+
+	res, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	res.Body = cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelOnClose releases the request's context once the body has been consumed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func newResponse(req *http.Request, body []byte) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
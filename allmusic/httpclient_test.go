@@ -0,0 +1,44 @@
+package allmusic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hulkhttp "github.com/ynori7/hulksmash/http"
+)
+
+func Test_TimeoutClient(t *testing.T) {
+	//given
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/hung" {
+			<-req.Context().Done()
+			return
+		}
+		rw.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	timeoutClient := NewTimeoutClient(hulkhttp.NewClientV2ForTests(server.Client().Transport), 50*time.Millisecond)
+
+	//when
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/fast", nil)
+	res, err := timeoutClient.Do(req)
+	require.NoError(t, err, "The fast request should succeed")
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	hungReq, _ := http.NewRequest(http.MethodGet, server.URL+"/hung", nil)
+	_, hungErr := timeoutClient.Do(hungReq)
+
+	//then
+	require.NoError(t, err, "The body should be readable before it's closed")
+	assert.Equal(t, "ok", string(body))
+	assert.True(t, errors.Is(hungErr, context.DeadlineExceeded), "The hung request should time out, got %v", hungErr)
+}
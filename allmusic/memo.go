@@ -0,0 +1,43 @@
+package allmusic
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoizingDiscographyProvider remembers the discographies which were already looked up during the run, so that
+// artists which show up in several weeks or with several releases are only fetched once.
+type MemoizingDiscographyProvider struct {
+	provider      DiscographyProvider
+	mutex         *sync.Mutex
+	discographies map[string]Discography
+}
+
+func NewMemoizingDiscographyProvider(provider DiscographyProvider) MemoizingDiscographyProvider {
+	return MemoizingDiscographyProvider{
+		provider:      provider,
+		mutex:         new(sync.Mutex),
+		discographies: make(map[string]Discography),
+	}
+}
+
+func (p MemoizingDiscographyProvider) GetArtistDiscography(ctx context.Context, link string) (*Discography, error) {
+	p.mutex.Lock()
+	discography, found := p.discographies[link]
+	p.mutex.Unlock()
+	if found {
+		return &discography, nil //a copy, so that the caller can modify it
+	}
+
+	fetched, err := p.provider.GetArtistDiscography(ctx, link)
+	if err != nil {
+		return nil, err //errors aren't remembered since they might be temporary
+	}
+
+	p.mutex.Lock()
+	p.discographies[link] = *fetched
+	p.mutex.Unlock()
+
+	discography = *fetched
+	return &discography, nil
+}
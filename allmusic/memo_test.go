@@ -0,0 +1,45 @@
+package allmusic
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingDiscographyProvider map[string]int
+
+func (p countingDiscographyProvider) GetArtistDiscography(ctx context.Context, link string) (*Discography, error) {
+	p[link]++
+	if link == "unknown" {
+		return nil, fmt.Errorf("status code error: 404 Not Found")
+	}
+	return &Discography{Artist: Artist{Name: link, Link: link}}, nil
+}
+
+func Test_MemoizingDiscographyProvider(t *testing.T) {
+	//given
+	counts := countingDiscographyProvider{}
+	provider := NewMemoizingDiscographyProvider(counts)
+
+	//when
+	first, err := provider.GetArtistDiscography(context.Background(), "king-diamond")
+	require.NoError(t, err)
+	first.Watchlisted = true //modifying the result must not affect later lookups
+
+	second, err := provider.GetArtistDiscography(context.Background(), "king-diamond")
+	require.NoError(t, err)
+
+	_, err1 := provider.GetArtistDiscography(context.Background(), "unknown")
+	_, err2 := provider.GetArtistDiscography(context.Background(), "unknown")
+
+	//then
+	assert.Equal(t, 1, counts["king-diamond"], "The discography should only be fetched once")
+	assert.Equal(t, "king-diamond", second.Artist.Name)
+	assert.False(t, second.Watchlisted)
+	assert.Error(t, err1)
+	assert.Error(t, err2)
+	assert.Equal(t, 2, counts["unknown"], "Errors should not be remembered")
+}
@@ -1,6 +1,7 @@
 package allmusic
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,7 +11,6 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/ynori7/hulksmash/anonymizer"
-	hulkhttp "github.com/ynori7/hulksmash/http"
 	"github.com/ynori7/music/config"
 )
 
@@ -35,30 +35,34 @@ type artist struct {
 }
 
 type ReleasesClient struct {
-	httpClient    *hulkhttp.ClientV2
-	conf          config.Config
+	httpClient    HttpClient
 	reqAnonymizer anonymizer.Anonymizer
 }
 
-func NewReleasesClient(conf config.Config) ReleasesClient {
+func NewReleasesClient(httpClient HttpClient) ReleasesClient {
 	return ReleasesClient{
-		httpClient:    hulkhttp.NewClientV2(),
-		conf:          conf,
+		httpClient:    httpClient,
 		reqAnonymizer: anonymizer.New(int64(rand.Int())),
 	}
 }
 
-func GetNewReleasesUrlForWeek(week string) string {
-	url := newReleasesUrl
-	if week != "" {
-		url = url + "/" + week
-	}
-	return url
+func GetNewReleasesUrlForWeek(week ReleaseWeek) string {
+	return newReleasesUrl + "/" + week.String()
+}
+
+// GetNewReleasesForWeek returns all new releases of the given week. Use FilterPotentiallyInteresting to get the ones
+// which are interesting for a profile.
+func (rc ReleasesClient) GetNewReleasesForWeek(ctx context.Context, week ReleaseWeek) ([]NewRelease, error) {
+	return rc.GetNewReleases(ctx, GetNewReleasesUrlForWeek(week))
 }
 
-func (rc ReleasesClient) GetPotentiallyInterestingNewReleases(url string) ([]NewRelease, error) {
+// GetNewReleases returns the new releases listed on the page, except for compilations and releases without an artist
+func (rc ReleasesClient) GetNewReleases(ctx context.Context, url string) ([]NewRelease, error) {
 	// Request the HTML page.
-	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
 	rc.reqAnonymizer.AnonymizeRequest(req)
 
 	res, err := rc.httpClient.Do(req)
@@ -112,11 +116,6 @@ func (rc ReleasesClient) GetPotentiallyInterestingNewReleases(url string) ([]New
 			return // No JSON data for this album
 		}
 
-		// Filter by genre using JSON data
-		if !rc.isInterestingGenre(albumData.Genre) {
-			return
-		}
-
 		// Filter out compilations
 		if isCompilation(albumData.Name) {
 			return
@@ -130,26 +129,66 @@ func (rc ReleasesClient) GetPotentiallyInterestingNewReleases(url string) ([]New
 		bandLink, _ := artistLink.Attr("href")
 
 		newReleases = append(newReleases, NewRelease{
+			ArtistName:    strings.TrimSpace(artistLink.Text()),
 			ArtistLink:    bandLink,
 			NewAlbumTitle: albumData.Name,
+			Genres:        splitGenres(albumData.Genre),
 		})
 	})
 
 	return newReleases, nil
 }
 
-func (rc ReleasesClient) isInterestingGenre(genre string) bool {
-	// Genre can be comma-separated, check each one
-	genres := strings.Split(genre, ",")
-	for _, g := range genres {
-		g = strings.TrimSpace(g)
-		if rc.conf.IsInterestingMainGenre(g) {
+// FilterPotentiallyInteresting returns the releases which are worth looking up the discography for
+func FilterPotentiallyInteresting(conf config.Config, releases []NewRelease) []NewRelease {
+	interesting := make([]NewRelease, 0)
+	for _, release := range releases {
+		if isInteresting(conf, release) {
+			interesting = append(interesting, release)
+		}
+	}
+	return interesting
+}
+
+// isInteresting checks the main genres of the release, or the new release rule if one is configured.
+// Excluded genres veto the release in either case, unless the artist is always included.
+func isInteresting(conf config.Config, release NewRelease) bool {
+	if conf.IsNeverIncluded(release.ArtistName, release.ArtistLink) {
+		return false
+	}
+	if conf.IsAlwaysIncluded(release.ArtistName, release.ArtistLink) {
+		return true
+	}
+
+	for _, g := range release.Genres {
+		if conf.IsExcludedMainGenre(g) {
+			return false
+		}
+	}
+
+	if r := conf.Rules.NewReleaseRule(); r != nil {
+		return r.Match(release.RuleEnv())
+	}
+
+	for _, g := range release.Genres {
+		if conf.IsInterestingMainGenre(g) {
 			return true
 		}
 	}
 	return false
 }
 
+// splitGenres splits the comma-separated genres of a release
+func splitGenres(genre string) []string {
+	genres := make([]string, 0)
+	for _, g := range strings.Split(genre, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			genres = append(genres, g)
+		}
+	}
+	return genres
+}
+
 var compilationIndicators = []string{"Live", "Compilation", "Best of", "Interview", "From the Vault", "Collection"}
 
 func isCompilation(title string) bool {
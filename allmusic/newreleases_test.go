@@ -1,6 +1,7 @@
 package allmusic
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -22,17 +23,83 @@ func Test_GetNewReleases(t *testing.T) {
 	}))
 	defer server.Close()
 
-	conf := config.Config{MainGenres: []string{"Rock", "Rap"}}
+	conf := config.Config{Profile: config.Profile{MainGenres: []string{"Rock", "Rap"}}}
 	newReleasesClient := ReleasesClient{
 		httpClient:    hulkhttp.NewClientV2ForTests(server.Client().Transport),
-		conf:          conf,
 		reqAnonymizer: anonymizer.New(12345),
 	}
 
 	//when
-	releases, err := newReleasesClient.GetPotentiallyInterestingNewReleases(server.URL)
+	allReleases, err := newReleasesClient.GetNewReleases(context.Background(), server.URL)
+	releases := FilterPotentiallyInteresting(conf, allReleases)
 
 	//then
 	require.NoError(t, err, "There was an error getting the releases")
+	assert.Greater(t, len(allReleases), len(releases), "Releases of other genres should be filtered")
 	assert.Equal(t, 89, len(releases))
 }
+
+func Test_GetNewReleases_Rule(t *testing.T) {
+	//given
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		dat, err := os.ReadFile("testdata/newreleases.html")
+		require.NoError(t, err, "There was an error reading the test data file")
+		rw.Write(dat)
+	}))
+	defer server.Close()
+
+	conf := config.Config{}
+	err := conf.Parse([]byte(`rules:
+  new_release: 'main_genre == "Rap" and not artist ~ "Lil"'`))
+	require.NoError(t, err, "There was an error parsing the config")
+
+	newReleasesClient := ReleasesClient{
+		httpClient:    hulkhttp.NewClientV2ForTests(server.Client().Transport),
+		reqAnonymizer: anonymizer.New(12345),
+	}
+
+	//when
+	allReleases, err := newReleasesClient.GetNewReleases(context.Background(), server.URL)
+	releases := FilterPotentiallyInteresting(conf, allReleases)
+
+	//then
+	require.NoError(t, err, "There was an error getting the releases")
+	require.NotEmpty(t, releases)
+	for _, release := range releases {
+		assert.Contains(t, release.Genres, "Rap")
+		assert.NotContains(t, release.ArtistName, "Lil")
+	}
+}
+
+func Test_GetNewReleases_AllowAndBlockLists(t *testing.T) {
+	//given
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		dat, err := os.ReadFile("testdata/newreleases.html")
+		require.NoError(t, err, "There was an error reading the test data file")
+		rw.Write(dat)
+	}))
+	defer server.Close()
+
+	conf := config.Config{Profile: config.Profile{
+		MainGenres:    []string{"Rock", "Rap"},
+		AlwaysInclude: []string{"1 800 Techno"},
+		NeverInclude:  []string{"Black Sabbath"},
+	}}
+	newReleasesClient := ReleasesClient{
+		httpClient:    hulkhttp.NewClientV2ForTests(server.Client().Transport),
+		reqAnonymizer: anonymizer.New(12345),
+	}
+
+	//when
+	allReleases, err := newReleasesClient.GetNewReleases(context.Background(), server.URL)
+	releases := FilterPotentiallyInteresting(conf, allReleases)
+
+	//then
+	require.NoError(t, err, "There was an error getting the releases")
+	artists := make([]string, 0, len(releases))
+	for _, release := range releases {
+		artists = append(artists, release.ArtistName)
+	}
+	assert.Contains(t, artists, "1 800 Techno", "The electronic artist should always be included")
+	assert.NotContains(t, artists, "Black Sabbath", "The rock artist should never be included")
+}
@@ -0,0 +1,29 @@
+package allmusic
+
+import (
+	"net/http"
+
+	"github.com/ynori7/music/config"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitedClient paces the requests with a token bucket. One instance is shared by all of the
+// allmusic clients so that the limit applies to the run as a whole.
+type RateLimitedClient struct {
+	httpClient HttpClient
+	limiter    *rate.Limiter
+}
+
+func NewRateLimitedClient(httpClient HttpClient, conf config.RateLimit) RateLimitedClient {
+	return RateLimitedClient{
+		httpClient: httpClient,
+		limiter:    rate.NewLimiter(rate.Limit(conf.RequestsPerSecond), conf.Burst),
+	}
+}
+
+func (c RateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return c.httpClient.Do(req)
+}
@@ -0,0 +1,36 @@
+package allmusic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hulkhttp "github.com/ynori7/hulksmash/http"
+	"github.com/ynori7/music/config"
+)
+
+func Test_RateLimitedClient(t *testing.T) {
+	//given
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	rateLimitedClient := NewRateLimitedClient(
+		hulkhttp.NewClientV2ForTests(server.Client().Transport),
+		config.RateLimit{RequestsPerSecond: 20, Burst: 1},
+	)
+
+	//when
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		res, err := rateLimitedClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+
+	//then
+	assert.True(t, time.Since(start) >= 90*time.Millisecond, "The requests should have been paced")
+}
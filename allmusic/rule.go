@@ -0,0 +1,28 @@
+package allmusic
+
+import "github.com/ynori7/music/rule"
+
+// RuleEnv returns the values of the release which can be used in a filter rule
+func (r NewRelease) RuleEnv() rule.Env {
+	env := rule.NewEnv()
+	env.Strings["artist"] = []string{r.ArtistName}
+	env.Strings["link"] = []string{r.ArtistLink}
+	env.Strings["album"] = []string{r.NewAlbumTitle}
+	env.Strings["main_genre"] = r.Genres
+	return env
+}
+
+// RuleEnv returns the values of the discography which can be used in a filter rule
+func (d Discography) RuleEnv() rule.Env {
+	env := rule.NewEnv()
+	env.Strings["artist"] = []string{d.Artist.Name}
+	env.Strings["link"] = []string{d.Artist.Link}
+	env.Strings["album"] = []string{d.NewestRelease.Title}
+	env.Strings["genre"] = d.Artist.Genres
+	env.Numbers["best_rating"] = float64(d.BestRating)
+	env.Numbers["average_rating"] = float64(d.AverageRating)
+	env.Numbers["rated_albums"] = float64(d.RatedAlbums())
+	env.Numbers["newest_rating"] = float64(d.NewestRelease.Rating)
+	env.Numbers["score"] = float64(d.Score)
+	return env
+}
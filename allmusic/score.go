@@ -0,0 +1,104 @@
+package allmusic
+
+import (
+	"math"
+
+	"github.com/ynori7/music/config"
+)
+
+// Scorer calculates the score of an artist according to the configured scoring model.
+// The score is always normalised to a value from 0 to 100.
+type Scorer struct {
+	conf config.Scoring
+}
+
+func NewScorer(conf config.Scoring) Scorer {
+	return Scorer{
+		conf: conf,
+	}
+}
+
+func (s Scorer) Score(discography Discography) int {
+	ratings := make([]int, 0, len(discography.Albums))
+	for _, album := range discography.Albums {
+		if album.Rating != 0 {
+			ratings = append(ratings, album.Rating)
+		}
+	}
+
+	average := float64(discography.AverageRating)
+	switch s.conf.Strategy {
+	case config.ScoringBayesian:
+		average = s.bayesianAverage(ratings)
+	case config.ScoringRecency:
+		average = s.recencyWeightedAverage(ratings)
+	}
+
+	return s.calculate(float64(discography.BestRating), average, len(ratings), discography.NewestRelease.Rating)
+}
+
+func (s Scorer) calculate(bestRating, averageRating float64, ratingCount, newestAlbumRating int) int {
+	score := bestRating*s.conf.BestRatingWeight + averageRating*s.conf.AverageRatingWeight
+
+	boost := s.conf.NewestReleaseBoost
+	if boost.Weight > 0 && newestAlbumRating >= boost.MinRating {
+		score = float64(newestAlbumRating) * boost.Weight //if the newest album is a well-rated one, use that as the base for the score to give it extra boost
+	}
+
+	//add a little extra weight based on the total number of ratings there were
+	score += s.ratingCountBonus(ratingCount)
+
+	maxScore := s.maxScore()
+	if maxScore <= 0 {
+		return 0
+	}
+	return int(math.Round(math.Min(100, math.Max(0, score*100/maxScore))))
+}
+
+func (s Scorer) ratingCountBonus(ratingCount int) float64 {
+	bonus := 0.0
+	for _, tier := range s.conf.RatingCountBonuses {
+		if ratingCount >= tier.MinCount && tier.Bonus > bonus {
+			bonus = tier.Bonus
+		}
+	}
+	return bonus
+}
+
+// maxScore is the score an artist with perfect ratings would get, which is used to normalise the score
+func (s Scorer) maxScore() float64 {
+	maxBase := s.conf.BestRatingWeight + s.conf.AverageRatingWeight
+	if s.conf.NewestReleaseBoost.Weight > maxBase {
+		maxBase = s.conf.NewestReleaseBoost.Weight
+	}
+	return maxBase*10 + s.ratingCountBonus(math.MaxInt)
+}
+
+// bayesianAverage pulls the average towards the prior rating so that a single great album counts for less
+// than a long history of great albums
+func (s Scorer) bayesianAverage(ratings []int) float64 {
+	sum := s.conf.PriorRating * s.conf.PriorWeight
+	for _, r := range ratings {
+		sum += float64(r)
+	}
+	count := s.conf.PriorWeight + float64(len(ratings))
+	if count == 0 {
+		return 0
+	}
+	return sum / count
+}
+
+// recencyWeightedAverage gives each album a weight which decays the further it is from the newest one.
+// The ratings are expected in chronological order, as they are listed in the discography.
+func (s Scorer) recencyWeightedAverage(ratings []int) float64 {
+	sum, weights, weight := 0.0, 0.0, 1.0
+	for i := len(ratings) - 1; i >= 0; i-- {
+		sum += float64(ratings[i]) * weight
+		weights += weight
+		weight *= s.conf.RecencyDecay
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sum / weights
+}
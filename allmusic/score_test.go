@@ -0,0 +1,92 @@
+package allmusic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ynori7/music/config"
+)
+
+func Test_Scorer_Score(t *testing.T) {
+	//a great debut with only one rating and a long career of decent albums which recently got better
+	debut := Discography{
+		Albums:        []Album{{Rating: 10}},
+		BestRating:    10,
+		AverageRating: 10,
+	}
+	improving := Discography{
+		Albums:        []Album{{Rating: 4}, {Rating: 5}, {Rating: 0}, {Rating: 8}, {Rating: 9}},
+		BestRating:    9,
+		AverageRating: 7,
+	}
+
+	testcases := map[string]struct {
+		Strategy            string
+		Discography         Discography
+		ExpectedScoreBounds [2]int
+	}{
+		"Weighted debut": {
+			Strategy:            config.ScoringWeighted,
+			Discography:         debut,
+			ExpectedScoreBounds: [2]int{80, 80},
+		},
+		"Bayesian debut": {
+			Strategy:    config.ScoringBayesian,
+			Discography: debut,
+			//(6*3 + 10) / 4 = 7 -> 10*4 + 7*4
+			ExpectedScoreBounds: [2]int{68, 68},
+		},
+		"Weighted improving": {
+			Strategy:    config.ScoringWeighted,
+			Discography: improving,
+			//9*4 + 7*4 + 10
+			ExpectedScoreBounds: [2]int{74, 74},
+		},
+		"Recency improving": {
+			Strategy:    config.ScoringRecency,
+			Discography: improving,
+			//the newer albums pull the average above the plain average
+			ExpectedScoreBounds: [2]int{75, 80},
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		scoring := config.DefaultScoring()
+		scoring.Strategy = testdata.Strategy
+
+		score := NewScorer(scoring).Score(testdata.Discography)
+
+		assert.True(t, score >= testdata.ExpectedScoreBounds[0] && score <= testdata.ExpectedScoreBounds[1],
+			"%s: expected a score between %d and %d but got %d", testcase, testdata.ExpectedScoreBounds[0], testdata.ExpectedScoreBounds[1], score)
+	}
+}
+
+func Test_Scorer_Normalised(t *testing.T) {
+	perfect := Discography{
+		Albums:        []Album{{Rating: 10}, {Rating: 10}, {Rating: 10}, {Rating: 10}, {Rating: 10}, {Rating: 10}, {Rating: 10}, {Rating: 10}},
+		BestRating:    10,
+		AverageRating: 10,
+		NewestRelease: Album{Rating: 10},
+	}
+
+	scorings := map[string]config.Scoring{
+		"Default": config.DefaultScoring(),
+		"Custom weights": {
+			Strategy:            config.ScoringWeighted,
+			BestRatingWeight:    1,
+			AverageRatingWeight: 3,
+			RatingCountBonuses:  []config.RatingCountBonus{{MinCount: 5, Bonus: 7}},
+		},
+		"No bonuses": {
+			Strategy:            config.ScoringWeighted,
+			BestRatingWeight:    5,
+			AverageRatingWeight: 5,
+			NewestReleaseBoost:  config.NewestReleaseBoost{MinRating: 9, Weight: 12},
+		},
+	}
+
+	for testcase, scoring := range scorings {
+		assert.Equal(t, 100, NewScorer(scoring).Score(perfect), testcase)
+		assert.Equal(t, 0, NewScorer(scoring).Score(Discography{}), testcase)
+	}
+}
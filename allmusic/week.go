@@ -0,0 +1,94 @@
+package allmusic
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" //the release timezone must be available even on systems without zoneinfo
+)
+
+const weekFormat = "20060102" //yyyyMMdd
+
+// releaseTimezone is the timezone in which allmusic publishes the new releases
+var releaseTimezone = mustLoadLocation("America/New_York")
+
+// ReleaseWeek is a week of new releases on allmusic. It's identified by the Friday on which the releases
+// are published and lasts until the following Thursday.
+type ReleaseWeek struct {
+	friday time.Time
+}
+
+// ParseReleaseWeek parses a date in the format yyyyMMdd and returns the release week which contains it, which is
+// the same one as ReleaseWeekOf returns for that day
+func ParseReleaseWeek(date string) (ReleaseWeek, error) {
+	parsed, err := time.ParseInLocation(weekFormat, date, releaseTimezone)
+	if err != nil {
+		return ReleaseWeek{}, fmt.Errorf("invalid release week %q, expected a date in the format yyyyMMdd: %w", date, err)
+	}
+	return ReleaseWeekOf(parsed), nil
+}
+
+// ReleaseWeekOf returns the release week which contains the given time in allmusic's timezone
+func ReleaseWeekOf(t time.Time) ReleaseWeek {
+	t = t.In(releaseTimezone)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, releaseTimezone)
+	daysSinceFriday := (int(day.Weekday()) - int(time.Friday) + 7) % 7
+	return ReleaseWeek{friday: day.AddDate(0, 0, -daysSinceFriday)}
+}
+
+// ReleaseWeeksBetween returns every release week from the one containing from until the one containing to
+func ReleaseWeeksBetween(from, to ReleaseWeek) ([]ReleaseWeek, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("the release week %s is after %s", from, to)
+	}
+
+	weeks := make([]ReleaseWeek, 0)
+	for week := from; !to.Before(week); week = week.Next() {
+		weeks = append(weeks, week)
+	}
+	return weeks, nil
+}
+
+// String returns the date of the release week in the format yyyyMMdd which is used by allmusic
+func (w ReleaseWeek) String() string {
+	return w.friday.Format(weekFormat)
+}
+
+// Format returns the date of the release week in the given layout
+func (w ReleaseWeek) Format(layout string) string {
+	return w.friday.Format(layout)
+}
+
+// Date returns midnight of the Friday on which the releases were published
+func (w ReleaseWeek) Date() time.Time {
+	return w.friday
+}
+
+func (w ReleaseWeek) IsZero() bool {
+	return w.friday.IsZero()
+}
+
+func (w ReleaseWeek) Before(other ReleaseWeek) bool {
+	return w.friday.Before(other.friday)
+}
+
+// Equal reports whether both are the same release week
+func (w ReleaseWeek) Equal(other ReleaseWeek) bool {
+	return w.friday.Equal(other.friday)
+}
+
+func (w ReleaseWeek) Next() ReleaseWeek {
+	return ReleaseWeek{friday: w.friday.AddDate(0, 0, 7)}
+}
+
+// AddWeeks returns the release week which is the given number of weeks later, or earlier for negative numbers
+func (w ReleaseWeek) AddWeeks(weeks int) ReleaseWeek {
+	return ReleaseWeek{friday: w.friday.AddDate(0, 0, 7*weeks)}
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
@@ -0,0 +1,132 @@
+package allmusic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseReleaseWeek(t *testing.T, date string) ReleaseWeek {
+	week, err := ParseReleaseWeek(date)
+	require.NoError(t, err, "There was an error parsing the release week")
+	return week
+}
+
+func Test_ParseReleaseWeek(t *testing.T) {
+	testcases := map[string]struct {
+		Date     string
+		Expected string
+	}{
+		"Friday": {
+			Date:     "20200327",
+			Expected: "20200327",
+		},
+		"Thursday belongs to the previous Friday": {
+			Date:     "20200326",
+			Expected: "20200320",
+		},
+		"Wednesday belongs to the previous Friday": {
+			Date:     "20200325",
+			Expected: "20200320",
+		},
+		"Sunday": {
+			Date:     "20200329",
+			Expected: "20200327",
+		},
+		"Across the new year": {
+			Date:     "20210103",
+			Expected: "20210101",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		week, err := ParseReleaseWeek(testdata.Date)
+		require.NoError(t, err, testcase)
+		assert.Equal(t, testdata.Expected, week.String(), testcase)
+		assert.Equal(t, time.Friday, week.Date().Weekday(), testcase)
+	}
+}
+
+func Test_ParseReleaseWeek_Invalid(t *testing.T) {
+	for _, date := range []string{"", "2020-03-27", "20200230", "27032020", "latest"} {
+		_, err := ParseReleaseWeek(date)
+		assert.Error(t, err, date)
+	}
+}
+
+func Test_ReleaseWeekOf(t *testing.T) {
+	//given
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	testcases := map[string]struct {
+		Time     time.Time
+		Expected string
+	}{
+		"Friday morning in Europe is still Thursday in New York": {
+			Time:     time.Date(2020, 3, 27, 2, 0, 0, 0, time.UTC),
+			Expected: "20200320",
+		},
+		"Friday in New York": {
+			Time:     time.Date(2020, 3, 27, 0, 0, 0, 0, newYork),
+			Expected: "20200327",
+		},
+		"Thursday night in New York": {
+			Time:     time.Date(2020, 3, 26, 23, 59, 0, 0, newYork),
+			Expected: "20200320",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		//when
+		week := ReleaseWeekOf(testdata.Time)
+
+		//then
+		assert.Equal(t, testdata.Expected, week.String(), testcase)
+	}
+}
+
+func Test_ReleaseWeeksBetween(t *testing.T) {
+	testcases := map[string]struct {
+		From     string
+		To       string
+		Expected []string
+	}{
+		"Single week": {
+			From:     "20200327",
+			To:       "20200327",
+			Expected: []string{"20200327"},
+		},
+		"Several weeks": {
+			From:     "20200306",
+			To:       "20200327",
+			Expected: []string{"20200306", "20200313", "20200320", "20200327"},
+		},
+		"Mid-week dates include the weeks containing them": {
+			From:     "20200302",
+			To:       "20200318",
+			Expected: []string{"20200228", "20200306", "20200313"},
+		},
+		"Across the daylight saving time change": {
+			From:     "20200306",
+			To:       "20200313",
+			Expected: []string{"20200306", "20200313"},
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		weeks, err := ReleaseWeeksBetween(mustParseReleaseWeek(t, testdata.From), mustParseReleaseWeek(t, testdata.To))
+		require.NoError(t, err, testcase)
+
+		actual := make([]string, 0, len(weeks))
+		for _, week := range weeks {
+			actual = append(actual, week.String())
+		}
+		assert.Equal(t, testdata.Expected, actual, testcase)
+	}
+
+	_, err := ReleaseWeeksBetween(mustParseReleaseWeek(t, "20200327"), mustParseReleaseWeek(t, "20200301"))
+	assert.Error(t, err, "The from week must not be after the to week")
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache is a simple on-disk key/value store where each entry is kept in its own file.
+// Entries expire based on the time they were written, so the TTL is chosen by the caller on lookup.
+type Cache struct {
+	directory string
+}
+
+// New returns a cache which stores its entries in the given directory, creating it if necessary.
+func New(directory string) (*Cache, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{directory: directory}, nil
+}
+
+// Get returns the entry for the key if it exists and isn't older than the ttl.
+func (c *Cache) Get(key string, ttl time.Duration) ([]byte, bool) {
+	path := c.path(key)
+
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > ttl {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set stores the data for the key, replacing any previous entry.
+func (c *Cache) Set(key string, data []byte) error {
+	//write to a temporary file first so that concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(c.directory, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c *Cache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.directory, hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetAndSet(t *testing.T) {
+	//given
+	c, err := New(t.TempDir())
+	require.NoError(t, err, "There was an error creating the cache")
+
+	//when
+	_, found := c.Get("https://www.allmusic.com/artist/king-diamond", time.Hour)
+	err = c.Set("https://www.allmusic.com/artist/king-diamond", []byte("<html></html>"))
+
+	//then
+	assert.False(t, found, "The entry should not exist before it was set")
+	require.NoError(t, err, "There was an error setting the entry")
+
+	data, found := c.Get("https://www.allmusic.com/artist/king-diamond", time.Hour)
+	assert.True(t, found)
+	assert.Equal(t, "<html></html>", string(data))
+
+	_, found = c.Get("https://www.allmusic.com/artist/mercyful-fate", time.Hour)
+	assert.False(t, found, "A different key should not be found")
+}
+
+func Test_Get_Expired(t *testing.T) {
+	//given
+	c, err := New(t.TempDir())
+	require.NoError(t, err, "There was an error creating the cache")
+	require.NoError(t, c.Set("key", []byte("value")))
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(c.path("key"), old, old))
+
+	//when
+	_, foundShortTTL := c.Get("key", time.Hour)
+	_, foundLongTTL := c.Get("key", 3*time.Hour)
+
+	//then
+	assert.False(t, foundShortTTL, "The entry should be expired")
+	assert.True(t, foundLongTTL, "The entry should still be valid")
+}
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/ynori7/music/allmusic"
 	"github.com/ynori7/music/config"
 	"github.com/ynori7/music/email"
+	"github.com/ynori7/music/history"
 	"github.com/ynori7/music/newreleases"
+	"github.com/ynori7/music/notify"
+	"github.com/ynori7/music/view"
 )
 
 func main() {
@@ -15,6 +25,7 @@ func main() {
 	})
 	log.SetLevel(log.DebugLevel)
 	logger := log.WithFields(log.Fields{"Logger": "main"})
+	startedAt := time.Now()
 
 	//Get the cli flags
 	config.ParseCliFlags()
@@ -22,6 +33,12 @@ func main() {
 		logger.Fatal("You must specify the path to the config file")
 	}
 
+	for _, format := range config.CliConf.Formats {
+		if !config.IsOutputFormat(format) {
+			logger.WithFields(log.Fields{"Format": format}).Fatal("Unknown output format, it must be html, json, csv or markdown")
+		}
+	}
+
 	//Get the config
 	data, err := os.ReadFile(config.CliConf.ConfigFile)
 	if err != nil {
@@ -33,17 +50,182 @@ func main() {
 		logger.WithFields(log.Fields{"error": err}).Fatal("Error parsing config")
 	}
 
-	//Generate the report
-	newReleasesHandler := newreleases.NewReleasesHandler(conf)
-	report, err := newReleasesHandler.GenerateNewReleasesReport(config.CliConf.NewReleaseWeek)
+	templates, err := view.LoadTemplates(conf.Templates.Html, conf.Templates.Text)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Fatal("Error loading templates")
+	}
+
+	//Determine the release week before anything is fetched so invalid input fails fast
+	week := allmusic.ReleaseWeekOf(time.Now())
+	if config.CliConf.NewReleaseWeek != "" {
+		if week, err = allmusic.ParseReleaseWeek(config.CliConf.NewReleaseWeek); err != nil {
+			logger.WithFields(log.Fields{"error": err}).Fatal("Invalid new release week")
+		}
+		if week.String() != config.CliConf.NewReleaseWeek {
+			logger.WithFields(log.Fields{"Date": config.CliConf.NewReleaseWeek, "Week": week.String()}).Warn("The date isn't a Friday, using the release week which contains it")
+		}
+	}
+
+	//Stop the run when it's interrupted or takes too long. Whatever was found until then is still reported
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if conf.Timeouts.Run > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, conf.Timeouts.Run)
+		defer cancel()
+	}
+
+	//Set up the allmusic clients which share one http client
+	httpClient, err := allmusic.NewHttpClient(conf, config.CliConf)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Fatal("Error setting up http client")
+	}
+
+	var discographyProvider allmusic.DiscographyProvider = allmusic.NewDiscographyClient(httpClient, conf.Scoring)
+
+	//Open the history of previous runs
+	var historyStore *history.Store
+	if conf.History.Enabled {
+		historyStore, err = history.Open(conf.History.Path)
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err}).Fatal("Error opening history")
+		}
+		defer historyStore.Close()
+		if config.CliConf.ReplayDirectory == "" { //reproducing a run doesn't change the history
+			discographyProvider = history.NewRecordingDiscographyProvider(discographyProvider, historyStore)
+		}
+	}
+
+	//Artists often release several albums in a row, so discographies are only fetched once per run
+	discographyProvider = allmusic.NewMemoizingDiscographyProvider(discographyProvider)
+
+	//Generate the reports
+	newReleasesHandler := newreleases.NewReleasesHandler(
+		conf,
+		allmusic.NewReleasesClient(httpClient),
+		discographyProvider,
+		historyStore,
+	).WithTemplates(templates)
+
+	var reports []newreleases.Report
+	if config.CliConf.Backfill {
+		weeks, err := getBackfillWeeks()
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err}).Fatal("Invalid backfill range")
+		}
+		reports, err = newReleasesHandler.GenerateBackfillReports(ctx, weeks, config.CliConf.Combine)
+	} else {
+		reports, err = newReleasesHandler.GenerateNewReleasesReports(ctx, week)
+	}
 	if err != nil {
-		logger.WithFields(log.Fields{"error": err}).Fatal("Unable to generate report")
+		logger.WithFields(log.Fields{"error": err}).Fatal("Unable to generate reports")
+	}
+	if ctx.Err() != nil {
+		logger.WithFields(log.Fields{"error": ctx.Err()}).Warn("The run was stopped early, the reports are incomplete")
 	}
 
+	//The reports are delivered even when the run was stopped early. Each delivery is limited by the request timeout instead
+	deliveryCtx := context.WithoutCancel(ctx)
+	summary := newreleases.NewRunSummary(startedAt, reports)
 	if conf.Email.Enabled {
-		mailer := email.NewMailer(conf)
-		if err := mailer.SendMail(email.GetNewReleasesSubjectLine(config.CliConf.NewReleaseWeek), report); err != nil {
-			logger.WithFields(log.Fields{"error": err}).Error("Error sending email")
+		sendEmails(deliveryCtx, conf, templates, reports, &summary)
+	}
+	sendNotifications(deliveryCtx, conf, reports, &summary)
+
+	//Only what reached someone is remembered, everything else is reported again by the next run
+	newReleasesHandler.RecordDelivered(reports, summary)
+
+	if err := summary.Save(filepath.Join(config.CliConf.OutputPath, "run-summary.json")); err != nil {
+		logger.WithFields(log.Fields{"error": err}).Warn("Error saving run summary")
+	}
+}
+
+// sendEmails sends the personalised reports to every subscriber and logs the deliveries which failed
+func sendEmails(ctx context.Context, conf config.Config, templates view.Templates, reports []newreleases.Report, summary *newreleases.RunSummary) {
+	logger := log.WithFields(log.Fields{"Logger": "sendEmails"})
+
+	emailReports := make([]email.Report, 0, len(reports))
+	for _, report := range reports {
+		emailReports = append(emailReports, email.Report{
+			Profile:       report.Config.Title,
+			From:          report.From,
+			To:            report.To,
+			Discographies: report.Discographies,
+		})
+	}
+
+	mailer := email.NewMailer(conf).WithTemplates(templates)
+	if conf.Email.InlineImages.Enabled {
+		//the covers are served by a CDN, so they don't go through the cache, fixtures and rate limits of allmusic
+		imageClient := &http.Client{Timeout: conf.Timeouts.Request}
+		mailer = mailer.WithImageEmbedder(email.NewImageEmbedder(imageClient, conf.Email.InlineImages.Size))
+	}
+
+	deliveries := mailer.SendToSubscribers(ctx, conf.GetSubscribers(), emailReports)
+	failed := 0
+	for _, delivery := range deliveries {
+		summary.AddDelivery("email", delivery.Subscriber.Address, delivery.Profile, delivery.From, delivery.To, delivery.Err)
+		if delivery.Err != nil {
+			failed++
+			logger.WithFields(log.Fields{
+				"error":      delivery.Err,
+				"Subscriber": delivery.Subscriber.Address,
+				"Profile":    delivery.Profile,
+			}).Error("Error sending email")
+		}
+	}
+	logger.WithFields(log.Fields{"Sent": len(deliveries) - failed, "Failed": failed}).Info("Finished sending emails")
+}
+
+// sendNotifications posts the reports to the configured chat channels and logs the deliveries which failed
+func sendNotifications(ctx context.Context, conf config.Config, reports []newreleases.Report, summary *newreleases.RunSummary) {
+	logger := log.WithFields(log.Fields{"Logger": "sendNotifications"})
+
+	//the chat services aren't allmusic, so they don't use its cache and rate limits
+	notifiers := notify.NewNotifiers(conf, &http.Client{Timeout: conf.Timeouts.Request})
+	if len(notifiers) == 0 {
+		return
+	}
+
+	notifyReports := make([]notify.Report, 0, len(reports))
+	for _, report := range reports {
+		notifyReports = append(notifyReports, notify.Report{
+			Profile:       report.Config.Title,
+			From:          report.From,
+			To:            report.To,
+			Discographies: report.Discographies,
+			HtmlFile:      report.HtmlFile,
+		})
+	}
+
+	deliveries := notify.NotifyAll(ctx, notifiers, notifyReports, conf.Timeouts.Request)
+	failed := 0
+	for _, delivery := range deliveries {
+		summary.AddDelivery(delivery.Notifier, delivery.Target, delivery.Profile, delivery.From, delivery.To, delivery.Err)
+		if delivery.Err != nil {
+			failed++
+			logger.WithFields(log.Fields{
+				"error":    delivery.Err,
+				"Notifier": delivery.Notifier,
+				"Target":   delivery.Target,
+				"Profile":  delivery.Profile,
+			}).Error("Error sending notification")
+		}
+	}
+	logger.WithFields(log.Fields{"Sent": len(deliveries) - failed, "Failed": failed}).Info("Finished sending notifications")
+}
+
+// getBackfillWeeks returns every release week between --from and --to
+func getBackfillWeeks() ([]allmusic.ReleaseWeek, error) {
+	from, err := allmusic.ParseReleaseWeek(config.CliConf.BackfillFrom)
+	if err != nil {
+		return nil, err
+	}
+	to := allmusic.ReleaseWeekOf(time.Now())
+	if config.CliConf.BackfillTo != "" {
+		if to, err = allmusic.ParseReleaseWeek(config.CliConf.BackfillTo); err != nil {
+			return nil, err
 		}
 	}
+	return allmusic.ReleaseWeeksBetween(from, to)
 }
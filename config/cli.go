@@ -1,23 +1,83 @@
 package config
 
-import "flag"
+import (
+	"flag"
+	"strings"
+)
 
 var CliConf CliConfig
 
+// The formats in which the reports can be saved
+const (
+	OutputHtml     = "html"
+	OutputJson     = "json"
+	OutputCsv      = "csv"
+	OutputMarkdown = "markdown"
+)
+
+// IsOutputFormat checks if the reports can be saved in the format
+func IsOutputFormat(format string) bool {
+	switch format {
+	case OutputHtml, OutputJson, OutputCsv, OutputMarkdown:
+		return true
+	}
+	return false
+}
+
 type CliConfig struct {
-	ConfigFile     string
-	NewReleaseWeek string //optional
-	OutputPath     string //optional
+	ConfigFile      string
+	NewReleaseWeek  string   //optional
+	OutputPath      string   //optional
+	NoCache         bool     //optional
+	RefreshCache    bool     //optional
+	RecordDirectory string   //optional
+	ReplayDirectory string   //optional
+	Resend          bool     //optional
+	Backfill        bool     //optional
+	BackfillFrom    string   //required in backfill mode
+	BackfillTo      string   //optional, defaults to today
+	Combine         bool     //optional
+	Formats         []string //optional, defaults to html
 }
 
 func ParseCliFlags() {
 	configFile := flag.String("config", "", "the path to the configuration yaml")
-	newReleaseWeek := flag.String("new-release-week", "", "the new release week (always a Thursday) in the format YYYYMMDD")
+	newReleaseWeek := flag.String("new-release-week", "", "the new release week in the format YYYYMMDD, any date from its Friday until the following Thursday can be given")
 	output := flag.String("output", "out", "the path where output files should be saved")
+	noCache := flag.Bool("no-cache", false, "don't read or write the allmusic response cache")
+	refresh := flag.Bool("refresh", false, "ignore cached allmusic responses and refresh the cache with new ones")
+	record := flag.String("record", "", "the path where all fetched allmusic responses should be saved as fixtures")
+	resend := flag.Bool("resend", false, "include albums in the report even if they were already reported in a previous run")
+	replay := flag.String("replay", "", "the path of previously recorded fixtures which should be served instead of calling allmusic")
+	backfill := flag.Bool("backfill", false, "generate reports for every release week between --from and --to")
+	from := flag.String("from", "", "the first date of the backfill in the format YYYYMMDD")
+	to := flag.String("to", "", "the last date of the backfill in the format YYYYMMDD (defaults to today)")
+	combine := flag.Bool("combine", false, "generate one combined backfill report instead of one report per week")
+	format := flag.String("format", OutputHtml, "comma-separated formats in which the reports should be saved (html, json, csv, markdown)")
 
 	flag.Parse()
 
 	CliConf.ConfigFile = *configFile
 	CliConf.NewReleaseWeek = *newReleaseWeek
 	CliConf.OutputPath = *output
+	CliConf.NoCache = *noCache
+	CliConf.RefreshCache = *refresh
+	CliConf.RecordDirectory = *record
+	CliConf.ReplayDirectory = *replay
+	CliConf.Resend = *resend
+	CliConf.Backfill = *backfill
+	CliConf.BackfillFrom = *from
+	CliConf.BackfillTo = *to
+	CliConf.Combine = *combine
+	CliConf.Formats = splitFormats(*format)
+}
+
+func splitFormats(formats string) []string {
+	result := make([]string, 0)
+	for _, f := range strings.Split(formats, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			result = append(result, f)
+		}
+	}
+	return result
 }
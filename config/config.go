@@ -1,29 +1,185 @@
 package config
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ynori7/music/rule"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Title      string
-	MainGenres []string  `yaml:"main_genres,flow"`
-	SubGenres  SubGenres `yaml:"sub_genres"`
-	Email      Email
+	Profile   `yaml:",inline"` //the profile which is used when no profiles are listed
+	Profiles  Profiles
+	Lists     map[string][]string
+	Email     Email
+	Cache     Cache
+	Timeouts  Timeouts
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Retry     Retry
+	Scoring   Scoring
+	History   History
+	Templates Templates
+	Feed      Feed
+	Notifiers Notifiers
+}
+
+// Profile defines what's interesting for one report. Several profiles share the fetched new releases and discographies.
+type Profile struct {
+	Title               string
+	MainGenres          []string        `yaml:"main_genres,flow"`
+	MainGenreExclusions GenreExclusions `yaml:"main_genre_exclusions"`
+	SubGenres           SubGenres       `yaml:"sub_genres"`
+	AlwaysInclude       []string        `yaml:"always_include"` //artist names or allmusic links which are included regardless of genre and ratings
+	NeverInclude        []string        `yaml:"never_include"`  //artist names or allmusic links which are never included
+	Thresholds          Thresholds
+	Rules               Rules
+	Recipients          []EmailRecipient //the email recipients of the full report. See Config.GetSubscribers
+	Formats             []string         `yaml:"formats,flow"`   //additional formats in which the report is saved, see CliConfig.Formats
+	MarkdownStyle       string           `yaml:"markdown_style"` //table or list
+}
+
+// The styles of the Markdown reports
+const (
+	MarkdownTable = "table"
+	MarkdownList  = "list"
+)
+
+// Profiles is the list of profiles which each get their own report
+type Profiles []Profile
+
+// UnmarshalYAML sets the defaults of each profile before it's parsed
+func (p *Profiles) UnmarshalYAML(value *yaml.Node) error {
+	var nodes []yaml.Node
+	if err := value.Decode(&nodes); err != nil {
+		return err
+	}
+
+	profiles := make(Profiles, 0, len(nodes))
+	for _, node := range nodes {
+		profile := Profile{Thresholds: defaultThresholds()}
+		if err := node.Decode(&profile); err != nil {
+			return err
+		}
+		profiles = append(profiles, profile)
+	}
+	*p = profiles
+	return nil
 }
 
 type SubGenres struct {
-	FuzzyMatches []string `yaml:"fuzzy_matches,flow"`
-	ExactMatches []string `yaml:"exact_matches,flow"`
+	FuzzyMatches    []string `yaml:"fuzzy_matches,flow"`
+	ExactMatches    []string `yaml:"exact_matches,flow"`
+	GenreExclusions `yaml:",inline"`
+}
+
+// GenreExclusions veto a release or an artist when any of its genres matches, even if other genres are interesting
+type GenreExclusions struct {
+	ExcludeFuzzy []string `yaml:"exclude_fuzzy,flow"`
+	ExcludeExact []string `yaml:"exclude_exact,flow"`
+}
+
+func (e GenreExclusions) isExcluded(genre string) bool {
+	return stringContainsListItem(genre, e.ExcludeFuzzy) || isContainedInList(genre, e.ExcludeExact)
+}
+
+// Thresholds define which ratings an artist needs to be considered interesting. Ratings are out of 10, so 4 stars is an 8.
+type Thresholds struct {
+	MinBestRating    int `yaml:"min_best_rating"`
+	MinAverageRating int `yaml:"min_average_rating"`
+	MinRatedAlbums   int `yaml:"min_rated_albums"`
+	MinNewestRating  int `yaml:"min_newest_rating"` //the rating of the new release itself
+	Debuts           Debuts
+}
+
+// Debuts allows artists without any ratings yet when enough of their styles are interesting sub-genres
+type Debuts struct {
+	Allowed         bool
+	MinGenreMatches int `yaml:"min_genre_matches"`
+}
+
+// Rules are filter expressions which replace the genre lists and thresholds when they're set.
+// See rule.Rule for the syntax. They're compiled when the config is parsed.
+type Rules struct {
+	NewRelease string `yaml:"new_release"` //replaces the main genre check of the new releases
+	Artist     string //replaces the sub-genre and rating checks of the artists
+
+	newRelease *rule.Rule
+	artist     *rule.Rule
+}
+
+// NewReleaseRule returns the compiled new release rule or nil if there is none
+func (r Rules) NewReleaseRule() *rule.Rule {
+	return r.newRelease
+}
+
+// ArtistRule returns the compiled artist rule or nil if there is none
+func (r Rules) ArtistRule() *rule.Rule {
+	return r.artist
+}
+
+func (r *Rules) compile(lists map[string][]string) error {
+	var err error
+	if r.NewRelease != "" {
+		if r.newRelease, err = rule.Compile(r.NewRelease, rule.NewReleaseFields, lists); err != nil {
+			return fmt.Errorf("invalid new_release rule: %w", err)
+		}
+	}
+	if r.Artist != "" {
+		if r.artist, err = rule.Compile(r.Artist, rule.DiscographyFields, lists); err != nil {
+			return fmt.Errorf("invalid artist rule: %w", err)
+		}
+	}
+	return nil
 }
 
 type Email struct {
-	Enabled    bool
-	PrivateKey string `yaml:"private_key"`
-	PublicKey  string `yaml:"public_key"`
-	From       EmailRecipient
-	To         EmailRecipient
+	Enabled      bool
+	Transport    string //mailjet (default) or smtp
+	PrivateKey   string `yaml:"private_key"` //mailjet only
+	PublicKey    string `yaml:"public_key"`  //mailjet only
+	Smtp         Smtp
+	From         EmailRecipient
+	To           EmailRecipient
+	Subscribers  []Subscriber
+	InlineImages InlineImages `yaml:"inline_images"`
+}
+
+// InlineImages attaches the cover and rating images to the emails instead of linking them, because many
+// mail clients block remote images
+type InlineImages struct {
+	Enabled bool
+	Size    int //the maximum width of the images in pixels, larger ones are shrunk
+}
+
+// The transports which can send the emails
+const (
+	TransportMailjet = "mailjet"
+	TransportSmtp    = "smtp"
+)
+
+// The ways of securing the connection to the SMTP server
+const (
+	SmtpStartTls = "starttls" //the connection is upgraded to TLS, usually on port 587
+	SmtpTls      = "tls"      //implicit TLS, usually on port 465
+	SmtpNone     = "none"     //unencrypted, only for local servers
+)
+
+// The SMTP authentication mechanisms
+const (
+	SmtpAuthPlain = "plain"
+	SmtpAuthLogin = "login"
+)
+
+// Smtp configures the SMTP server which is used by the smtp transport
+type Smtp struct {
+	Host     string
+	Port     int
+	Username string //no authentication when empty
+	Password string
+	Security string //starttls (default), tls or none
+	Auth     string //plain (default) or login
 }
 
 type EmailRecipient struct {
@@ -31,21 +187,511 @@ type EmailRecipient struct {
 	Name    string
 }
 
+// The formats in which the reports can be sent
+const (
+	FormatHtml = "html"
+	FormatText = "text"
+)
+
+// Subscriber receives a personalised report for each of the profiles they're subscribed to
+type Subscriber struct {
+	EmailRecipient `yaml:",inline"`
+	Profiles       []string `yaml:",flow"` //the titles of the profiles. All profiles when empty
+	Format         string   //html (default) or text
+	MinScore       int      `yaml:"min_score"` //releases with a lower score are left out of the report
+	Cc             []EmailRecipient
+	Bcc            []EmailRecipient
+}
+
+// IsSubscribedTo checks if the subscriber receives the reports of the profile
+func (s Subscriber) IsSubscribedTo(profile string) bool {
+	return len(s.Profiles) == 0 || isContainedInList(profile, s.Profiles)
+}
+
+// Timeouts limit how long the whole run and each individual request to allmusic may take
+type Timeouts struct {
+	Run     time.Duration
+	Request time.Duration
+}
+
+// RateLimit configures the token bucket which paces all requests to allmusic
+type RateLimit struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int
+}
+
+// Retry configures how failed requests are retried
+type Retry struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// The strategies for calculating the average rating component of the score
+const (
+	ScoringWeighted = "weighted" //the plain average of all ratings
+	ScoringBayesian = "bayesian" //the average is pulled towards a prior rating when there are few ratings
+	ScoringRecency  = "recency"  //newer albums count more towards the average than older ones
+)
+
+// Scoring defines how the score of an artist is calculated from their ratings
+type Scoring struct {
+	Strategy            string
+	BestRatingWeight    float64            `yaml:"best_rating_weight"`
+	AverageRatingWeight float64            `yaml:"average_rating_weight"`
+	NewestReleaseBoost  NewestReleaseBoost `yaml:"newest_release_boost"`
+	RatingCountBonuses  []RatingCountBonus `yaml:"rating_count_bonuses"`
+	PriorRating         float64            `yaml:"prior_rating"`  //only used by the bayesian strategy
+	PriorWeight         float64            `yaml:"prior_weight"`  //only used by the bayesian strategy
+	RecencyDecay        float64            `yaml:"recency_decay"` //only used by the recency strategy
+}
+
+// NewestReleaseBoost replaces the rating based part of the score when the newest release is rated well
+type NewestReleaseBoost struct {
+	MinRating int `yaml:"min_rating"`
+	Weight    float64
+}
+
+// RatingCountBonus is added to the score when the artist has at least MinCount rated albums
+type RatingCountBonus struct {
+	MinCount int `yaml:"min_count"`
+	Bonus    float64
+}
+
+// DefaultScoring returns the scoring model which is used unless the config defines a different one
+func DefaultScoring() Scoring {
+	return Scoring{
+		Strategy:            ScoringWeighted,
+		BestRatingWeight:    4, //40% of the score is from the best rating (gives some extra weight to the average)
+		AverageRatingWeight: 4, //another 40% is from the average rating
+		NewestReleaseBoost:  NewestReleaseBoost{MinRating: 8, Weight: 8},
+		RatingCountBonuses: []RatingCountBonus{
+			{MinCount: 8, Bonus: 20},
+			{MinCount: 6, Bonus: 15},
+			{MinCount: 3, Bonus: 10},
+			{MinCount: 2, Bonus: 5},
+		},
+		PriorRating:  6,
+		PriorWeight:  3,
+		RecencyDecay: 0.75,
+	}
+}
+
+// History is the local database which remembers the releases which were already reported
+type History struct {
+	Enabled bool
+	Path    string
+}
+
+// Notifiers post the top releases of the reports to chat channels
+type Notifiers struct {
+	Slack    []ChatWebhook //slack incoming webhooks
+	Discord  []ChatWebhook
+	Matrix   []MatrixRoom
+	Webhooks []Webhook //receive the full reports as signed json
+	Push     []Push    //short summaries for phones
+	Retry    Retry     //how failed webhook deliveries are retried within the request timeout
+}
+
+// Channel defines what's posted to a chat channel
+type Channel struct {
+	Profiles []string `yaml:",flow"` //the titles of the profiles. All profiles when empty
+	Top      int      //how many of the releases are posted, 5 when empty
+}
+
+func (c Channel) IsSubscribedTo(profile string) bool {
+	return len(c.Profiles) == 0 || isContainedInList(profile, c.Profiles)
+}
+
+type ChatWebhook struct {
+	Channel `yaml:",inline"`
+	Url     string
+}
+
+type MatrixRoom struct {
+	Channel     `yaml:",inline"`
+	Homeserver  string //e.g. https://matrix.org
+	RoomId      string `yaml:"room_id"` //e.g. !abcdef:matrix.org
+	AccessToken string `yaml:"access_token"`
+}
+
+// Webhook receives the json report of each profile. Failed deliveries are retried according to the retry section.
+type Webhook struct {
+	Url      string
+	Secret   string   //optional, the body is signed with it in the X-Signature-256 header
+	Profiles []string `yaml:",flow"` //the titles of the profiles. All profiles when empty
+}
+
+func (w Webhook) IsSubscribedTo(profile string) bool {
+	return len(w.Profiles) == 0 || isContainedInList(profile, w.Profiles)
+}
+
+// The services which can receive push notifications
+const (
+	PushNtfy   = "ntfy"
+	PushGotify = "gotify"
+)
+
+// Push sends a short summary of each report to an ntfy or Gotify server
+type Push struct {
+	Service    string   //ntfy or gotify
+	Url        string   //the server, e.g. https://ntfy.sh
+	Topic      string   //ntfy only
+	Token      string   //the application token for gotify, an optional access token for ntfy
+	ReportsUrl string   `yaml:"reports_url"` //optional, the url where the output directory is published. Notifications link to the html report there
+	Profiles   []string `yaml:",flow"`       //the titles of the profiles. All profiles when empty
+}
+
+func (p Push) IsSubscribedTo(profile string) bool {
+	return len(p.Profiles) == 0 || isContainedInList(profile, p.Profiles)
+}
+
+// Feed is a rolling Atom and RSS feed of the releases which were reported in the last weeks. It's built from the history.
+type Feed struct {
+	Enabled bool
+	Weeks   int    //how many release weeks the feed contains
+	Url     string //optional, the url where the output directory is published
+}
+
+// Templates are the paths of custom report templates. The built-in templates are used when they're empty.
+type Templates struct {
+	Html string
+	Text string
+}
+
+type Cache struct {
+	Enabled   bool
+	Directory string
+	TTL       CacheTTL `yaml:"ttl"`
+}
+
+// CacheTTL defines how long each type of allmusic page is cached
+type CacheTTL struct {
+	NewReleases time.Duration `yaml:"new_releases"`
+	Artist      time.Duration `yaml:"artist"`
+	Discography time.Duration `yaml:"discography"`
+}
+
 /**
  * Parse the contents of the YAML file into the Config object.
  */
 func (c *Config) Parse(data []byte) error {
-	return yaml.Unmarshal(data, &c)
+	c.setDefaults()
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	return c.validate()
+}
+
+func (c *Config) validate() error {
+	switch c.Scoring.Strategy {
+	case ScoringWeighted, ScoringBayesian, ScoringRecency:
+	default:
+		return fmt.Errorf("unknown scoring strategy: %s", c.Scoring.Strategy)
+	}
+
+	if err := c.validateRequests(); err != nil {
+		return err
+	}
+	if err := c.validateEmail(); err != nil {
+		return err
+	}
+	if err := c.validateSubscribers(); err != nil {
+		return err
+	}
+	if err := c.validateNotifiers(); err != nil {
+		return err
+	}
+	if c.Feed.Enabled && !c.History.Enabled {
+		return fmt.Errorf("the feed requires the history to be enabled")
+	}
+	if c.Feed.Enabled && c.Feed.Weeks < 1 {
+		return fmt.Errorf("the feed must contain at least one week")
+	}
+
+	if len(c.Profiles) == 0 {
+		if err := c.Profile.validateOutput(); err != nil {
+			return err
+		}
+		return c.Rules.compile(c.Lists)
+	}
+
+	titles := make(map[string]bool)
+	for i := range c.Profiles {
+		title := c.Profiles[i].Title
+		if title == "" {
+			return fmt.Errorf("profile %d has no title", i+1)
+		}
+		if titles[title] {
+			return fmt.Errorf("duplicate profile title: %s", title)
+		}
+		titles[title] = true
+
+		if err := c.Profiles[i].validateOutput(); err != nil {
+			return fmt.Errorf("profile %s: %w", title, err)
+		}
+		if err := c.Profiles[i].Rules.compile(c.Lists); err != nil {
+			return fmt.Errorf("profile %s: %w", title, err)
+		}
+	}
+	return nil
+}
+
+func (p *Profile) validateOutput() error {
+	for _, format := range p.Formats {
+		if !IsOutputFormat(format) {
+			return fmt.Errorf("unknown output format: %s", format)
+		}
+	}
+	switch p.MarkdownStyle {
+	case "", MarkdownTable, MarkdownList:
+	default:
+		return fmt.Errorf("unknown markdown style: %s", p.MarkdownStyle)
+	}
+	return nil
+}
+
+func (c *Config) validateRequests() error {
+	if c.RateLimit.RequestsPerSecond > 0 && c.RateLimit.Burst < 1 {
+		return fmt.Errorf("the rate_limit burst must be at least 1")
+	}
+	if err := c.Retry.validate(); err != nil {
+		return err
+	}
+	if err := c.Notifiers.Retry.validate(); err != nil {
+		return fmt.Errorf("notifiers: %w", err)
+	}
+	return nil
+}
+
+func (r Retry) validate() error {
+	if r.MaxAttempts > 1 && r.MaxBackoff <= 0 {
+		return fmt.Errorf("the retry max_backoff must be greater than zero when requests are retried")
+	}
+	return nil
+}
+
+func (c *Config) validateEmail() error {
+	switch c.Email.Transport {
+	case TransportMailjet:
+	case TransportSmtp:
+		if c.Email.Enabled && c.Email.Smtp.Host == "" {
+			return fmt.Errorf("the smtp transport requires a host")
+		}
+		switch c.Email.Smtp.Security {
+		case SmtpStartTls, SmtpTls, SmtpNone:
+		default:
+			return fmt.Errorf("unknown smtp security: %s", c.Email.Smtp.Security)
+		}
+		switch c.Email.Smtp.Auth {
+		case SmtpAuthPlain, SmtpAuthLogin:
+		default:
+			return fmt.Errorf("unknown smtp auth: %s", c.Email.Smtp.Auth)
+		}
+	default:
+		return fmt.Errorf("unknown email transport: %s", c.Email.Transport)
+	}
+	return nil
+}
+
+func (c *Config) validateSubscribers() error {
+	titles := make(map[string]bool)
+	for _, profile := range c.ProfileConfigs() {
+		titles[profile.Title] = true
+	}
+
+	for _, subscriber := range c.Email.Subscribers {
+		if subscriber.Address == "" {
+			return fmt.Errorf("subscriber %s has no address", subscriber.Name)
+		}
+		switch subscriber.Format {
+		case "", FormatHtml, FormatText:
+		default:
+			return fmt.Errorf("unknown format of subscriber %s: %s", subscriber.Address, subscriber.Format)
+		}
+		for _, profile := range subscriber.Profiles {
+			if !titles[profile] {
+				return fmt.Errorf("subscriber %s is subscribed to unknown profile: %s", subscriber.Address, profile)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateNotifiers() error {
+	titles := make(map[string]bool)
+	for _, profile := range c.ProfileConfigs() {
+		titles[profile.Title] = true
+	}
+	validateChannel := func(name string, channel Channel) error {
+		for _, profile := range channel.Profiles {
+			if !titles[profile] {
+				return fmt.Errorf("%s notifier is subscribed to unknown profile: %s", name, profile)
+			}
+		}
+		return nil
+	}
+
+	for name, webhooks := range map[string][]ChatWebhook{"slack": c.Notifiers.Slack, "discord": c.Notifiers.Discord} {
+		for _, webhook := range webhooks {
+			if webhook.Url == "" {
+				return fmt.Errorf("%s notifier has no url", name)
+			}
+			if err := validateChannel(name, webhook.Channel); err != nil {
+				return err
+			}
+		}
+	}
+	for _, room := range c.Notifiers.Matrix {
+		if room.Homeserver == "" || room.RoomId == "" || room.AccessToken == "" {
+			return fmt.Errorf("matrix notifier requires a homeserver, room_id and access_token")
+		}
+		if err := validateChannel("matrix", room.Channel); err != nil {
+			return err
+		}
+	}
+	for _, push := range c.Notifiers.Push {
+		switch {
+		case push.Service != PushNtfy && push.Service != PushGotify:
+			return fmt.Errorf("unknown push service: %s", push.Service)
+		case push.Url == "":
+			return fmt.Errorf("%s notifier has no url", push.Service)
+		case push.Service == PushNtfy && push.Topic == "":
+			return fmt.Errorf("ntfy notifier requires a topic")
+		case push.Service == PushGotify && push.Token == "":
+			return fmt.Errorf("gotify notifier requires a token")
+		}
+		if err := validateChannel(push.Service, Channel{Profiles: push.Profiles}); err != nil {
+			return err
+		}
+	}
+	for _, webhook := range c.Notifiers.Webhooks {
+		if webhook.Url == "" {
+			return fmt.Errorf("webhook notifier has no url")
+		}
+		if err := validateChannel("webhook", Channel{Profiles: webhook.Profiles}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (c *Config) IsInterestingMainGenre(genre string) bool {
+func (c *Config) setDefaults() {
+	c.Thresholds = defaultThresholds()
+	c.Timeouts = Timeouts{
+		Run:     30 * time.Minute,
+		Request: 30 * time.Second,
+	}
+	c.RateLimit = RateLimit{
+		RequestsPerSecond: 2,
+		Burst:             5,
+	}
+	c.Retry = Retry{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+	}
+	c.Notifiers.Retry = Retry{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}
+	c.Scoring = DefaultScoring()
+	c.Email.Transport = TransportMailjet
+	c.Email.InlineImages.Size = 192
+	c.Email.Smtp = Smtp{
+		Port:     587,
+		Security: SmtpStartTls,
+		Auth:     SmtpAuthPlain,
+	}
+	c.History.Path = "history.db"
+	c.Feed.Weeks = 8
+	c.Cache.Directory = ".cache"
+	c.Cache.TTL = CacheTTL{
+		NewReleases: 6 * time.Hour,
+		Artist:      7 * 24 * time.Hour,
+		Discography: 7 * 24 * time.Hour,
+	}
+}
+
+func defaultThresholds() Thresholds {
+	return Thresholds{
+		MinBestRating: 8,
+		Debuts:        Debuts{MinGenreMatches: 2},
+	}
+}
+
+// ProfileConfigs returns a config for each of the profiles, or only the config itself when no profiles are listed
+func (c Config) ProfileConfigs() []Config {
+	if len(c.Profiles) == 0 {
+		return []Config{c}
+	}
+
+	configs := make([]Config, 0, len(c.Profiles))
+	for _, profile := range c.Profiles {
+		conf := c
+		conf.Profile = profile
+		conf.Profiles = nil
+		configs = append(configs, conf)
+	}
+	return configs
+}
+
+// GetSubscribers returns the subscribers of the email section as well as the recipients of the profiles, which receive
+// the full HTML report of their profile. Profiles without recipients are sent to the "to" address unless there are
+// subscribers.
+func (c Config) GetSubscribers() []Subscriber {
+	subscribers := append([]Subscriber{}, c.Email.Subscribers...)
+	for _, profile := range c.ProfileConfigs() {
+		recipients := profile.Recipients
+		if len(recipients) == 0 && len(c.Email.Subscribers) == 0 {
+			recipients = []EmailRecipient{c.Email.To}
+		}
+		for _, recipient := range recipients {
+			if recipient.Address == "" {
+				continue
+			}
+			subscribers = append(subscribers, Subscriber{EmailRecipient: recipient, Profiles: []string{profile.Title}})
+		}
+	}
+	return subscribers
+}
+
+func (c *Profile) IsInterestingMainGenre(genre string) bool {
 	return stringContainsListItem(genre, c.MainGenres)
 }
 
-func (c *Config) IsInterestingSubGenre(genre string) bool {
+func (c *Profile) IsInterestingSubGenre(genre string) bool {
 	return stringContainsListItem(genre, c.SubGenres.FuzzyMatches) || isContainedInList(genre, c.SubGenres.ExactMatches)
 }
 
+func (c *Profile) IsExcludedMainGenre(genre string) bool {
+	return c.MainGenreExclusions.isExcluded(genre)
+}
+
+func (c *Profile) IsExcludedSubGenre(genre string) bool {
+	return c.SubGenres.isExcluded(genre)
+}
+
+func (c *Profile) IsAlwaysIncluded(artistName, artistLink string) bool {
+	return matchesArtist(artistName, artistLink, c.AlwaysInclude)
+}
+
+func (c *Profile) IsNeverIncluded(artistName, artistLink string) bool {
+	return matchesArtist(artistName, artistLink, c.NeverInclude)
+}
+
+// matchesArtist checks if the artist's name (ignoring case) or link is contained in the list
+func matchesArtist(artistName, artistLink string, list []string) bool {
+	artistLink = strings.TrimSuffix(artistLink, "/")
+	for _, s := range list {
+		if (artistName != "" && strings.EqualFold(s, artistName)) || (artistLink != "" && strings.TrimSuffix(s, "/") == artistLink) {
+			return true
+		}
+	}
+	return false
+}
+
 func stringContainsListItem(str string, list []string) bool {
 	for _, s := range list {
 		if strings.Contains(str, s) {
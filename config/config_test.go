@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -47,6 +48,549 @@ email:
 	assert.Equal(t, c.Email.PublicKey, "public456")
 	assert.Equal(t, c.Email.From.Address, "no-reply@something.com")
 	assert.Equal(t, c.Email.To.Name, "Me")
+	assert.Equal(t, 30*time.Minute, c.Timeouts.Run, "The default run timeout should be used")
+	assert.Equal(t, 30*time.Second, c.Timeouts.Request, "The default request timeout should be used")
+}
+
+func Test_Parse_Cache(t *testing.T) {
+	testcases := map[string]struct {
+		Config   []byte
+		Expected Cache
+	}{
+		"Defaults": {
+			Config: []byte(`title: "rap-and-metal"`),
+			Expected: Cache{
+				Enabled:   false,
+				Directory: ".cache",
+				TTL:       CacheTTL{NewReleases: 6 * time.Hour, Artist: 168 * time.Hour, Discography: 168 * time.Hour},
+			},
+		},
+		"Overridden": {
+			Config: []byte(`cache:
+  enabled: true
+  directory: "/tmp/allmusic"
+  ttl:
+    new_releases: 30m
+    artist: 24h`),
+			Expected: Cache{
+				Enabled:   true,
+				Directory: "/tmp/allmusic",
+				TTL:       CacheTTL{NewReleases: 30 * time.Minute, Artist: 24 * time.Hour, Discography: 168 * time.Hour},
+			},
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		c := Config{}
+		err := c.Parse(testdata.Config)
+		require.NoError(t, err, testcase)
+		assert.Equal(t, testdata.Expected, c.Cache, testcase)
+	}
+}
+
+func Test_Parse_Scoring(t *testing.T) {
+	//given
+	testConfig := []byte(`scoring:
+  strategy: "bayesian"
+  best_rating_weight: 5
+  rating_count_bonuses:
+    - min_count: 4
+      bonus: 10`)
+
+	//when
+	c := Config{}
+	err := c.Parse(testConfig)
+
+	//then
+	require.NoError(t, err, "It should parse the config successfully")
+	assert.Equal(t, ScoringBayesian, c.Scoring.Strategy)
+	assert.Equal(t, 5.0, c.Scoring.BestRatingWeight)
+	assert.Equal(t, 4.0, c.Scoring.AverageRatingWeight, "The default weight should be kept")
+	assert.Equal(t, []RatingCountBonus{{MinCount: 4, Bonus: 10}}, c.Scoring.RatingCountBonuses, "The tiers should be replaced")
+}
+
+func Test_Parse_Thresholds(t *testing.T) {
+	c := Config{}
+	err := c.Parse([]byte(`thresholds:
+  min_average_rating: 6
+  debuts:
+    allowed: true`))
+
+	require.NoError(t, err, "It should parse the config successfully")
+	assert.Equal(t, Thresholds{
+		MinBestRating:    8,
+		MinAverageRating: 6,
+		Debuts:           Debuts{Allowed: true, MinGenreMatches: 2},
+	}, c.Thresholds, "The configured thresholds should be merged with the defaults")
+}
+
+func Test_Parse_Rules(t *testing.T) {
+	//given
+	testConfig := []byte(`lists:
+  watchlist: ["King Diamond"]
+rules:
+  new_release: 'main_genre ~ "Rock"'
+  artist: 'genre ~ "Metal" and best_rating >= 8 or artist in watchlist'`)
+
+	//when
+	c := Config{}
+	err := c.Parse(testConfig)
+
+	//then
+	require.NoError(t, err, "It should parse the config successfully")
+	require.NotNil(t, c.Rules.NewReleaseRule())
+	require.NotNil(t, c.Rules.ArtistRule())
+	assert.Equal(t, `genre ~ "Metal" and best_rating >= 8 or artist in watchlist`, c.Rules.ArtistRule().String())
+}
+
+func Test_Parse_InvalidRule(t *testing.T) {
+	testcases := map[string]struct {
+		Config        string
+		ExpectedError string
+	}{
+		"Unknown list": {
+			Config: `rules:
+  artist: 'artist in watchlist'`,
+			ExpectedError: `invalid artist rule: unknown list "watchlist" at position 11`,
+		},
+		"Discography field in new release rule": {
+			Config: `rules:
+  new_release: 'best_rating >= 8'`,
+			ExpectedError: `invalid new_release rule: unknown field "best_rating" (valid fields are album, artist, link, main_genre) at position 1`,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		c := Config{}
+		err := c.Parse([]byte(testdata.Config))
+
+		require.Error(t, err, testcase)
+		assert.Equal(t, testdata.ExpectedError, err.Error(), testcase)
+	}
+}
+
+func Test_Parse_Profiles(t *testing.T) {
+	c := Config{}
+	err := c.Parse([]byte(`lists:
+  watchlist: ["King Diamond"]
+email:
+  to:
+    address: "me@mysite.com"
+profiles:
+  - title: "metal"
+    main_genres: ["Rock"]
+    rules:
+      artist: 'artist in watchlist'
+  - title: "jazz"
+    main_genres: ["Jazz"]
+    thresholds:
+      min_best_rating: 6
+    recipients:
+      - address: "jazz@mysite.com"`))
+	require.NoError(t, err, "It should parse the config successfully")
+
+	profiles := c.ProfileConfigs()
+	require.Len(t, profiles, 2)
+
+	assert.Equal(t, "metal", profiles[0].Title)
+	assert.Equal(t, []string{"Rock"}, profiles[0].MainGenres)
+	assert.Equal(t, 8, profiles[0].Thresholds.MinBestRating, "The default thresholds should be used")
+	assert.NotNil(t, profiles[0].Rules.ArtistRule(), "The rule should be compiled with the shared lists")
+
+	assert.Equal(t, "jazz", profiles[1].Title)
+	assert.Equal(t, 6, profiles[1].Thresholds.MinBestRating)
+	assert.Equal(t, 2, profiles[1].Thresholds.Debuts.MinGenreMatches, "The other thresholds should keep their defaults")
+
+	assert.Equal(t, []Subscriber{
+		{EmailRecipient: EmailRecipient{Address: "me@mysite.com"}, Profiles: []string{"metal"}},
+		{EmailRecipient: EmailRecipient{Address: "jazz@mysite.com"}, Profiles: []string{"jazz"}},
+	}, c.GetSubscribers(), "Profiles without recipients should be sent to the default address")
+}
+
+func Test_Parse_WithoutProfiles(t *testing.T) {
+	c := Config{}
+	err := c.Parse([]byte(`title: "rap-and-metal"`))
+	require.NoError(t, err, "It should parse the config successfully")
+
+	profiles := c.ProfileConfigs()
+	require.Len(t, profiles, 1)
+	assert.Equal(t, "rap-and-metal", profiles[0].Title)
+}
+
+func Test_Parse_InvalidProfiles(t *testing.T) {
+	testcases := map[string]struct {
+		Config   []byte
+		Expected string
+	}{
+		"Missing title": {
+			Config: []byte(`profiles:
+  - main_genres: ["Rock"]`),
+			Expected: "profile 1 has no title",
+		},
+		"Duplicate title": {
+			Config: []byte(`profiles:
+  - title: "metal"
+  - title: "metal"`),
+			Expected: "duplicate profile title: metal",
+		},
+		"Invalid rule": {
+			Config: []byte(`profiles:
+  - title: "metal"
+    rules:
+      artist: 'artist in watchlist'`),
+			Expected: `profile metal: invalid artist rule: unknown list "watchlist" at position 11`,
+		},
+		"Unknown format": {
+			Config: []byte(`profiles:
+  - title: "metal"
+    formats: ["pdf"]`),
+			Expected: "profile metal: unknown output format: pdf",
+		},
+		"Unknown markdown style": {
+			Config:   []byte(`markdown_style: "grid"`),
+			Expected: "unknown markdown style: grid",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		c := Config{}
+		err := c.Parse(testdata.Config)
+
+		require.Error(t, err, testcase)
+		assert.Equal(t, testdata.Expected, err.Error(), testcase)
+	}
+}
+
+func Test_Parse_Subscribers(t *testing.T) {
+	c := Config{}
+	err := c.Parse([]byte(`profiles:
+  - title: "metal"
+  - title: "jazz"
+    recipients:
+      - address: "jazz@mysite.com"
+email:
+  to:
+    address: "me@mysite.com"
+  subscribers:
+    - address: "metalhead@mysite.com"
+      name: "Metalhead"
+      profiles: ["metal"]
+      format: "text"
+      min_score: 70
+      cc:
+        - address: "friend@mysite.com"
+    - address: "everything@mysite.com"
+      bcc:
+        - address: "archive@mysite.com"`))
+	require.NoError(t, err, "It should parse the config successfully")
+
+	subscribers := c.GetSubscribers()
+	require.Len(t, subscribers, 3, "The default address should not be used when there are subscribers")
+
+	assert.Equal(t, "Metalhead", subscribers[0].Name)
+	assert.Equal(t, FormatText, subscribers[0].Format)
+	assert.Equal(t, 70, subscribers[0].MinScore)
+	assert.Equal(t, []EmailRecipient{{Address: "friend@mysite.com"}}, subscribers[0].Cc)
+	assert.True(t, subscribers[0].IsSubscribedTo("metal"))
+	assert.False(t, subscribers[0].IsSubscribedTo("jazz"))
+
+	assert.Equal(t, "everything@mysite.com", subscribers[1].Address)
+	assert.Equal(t, []EmailRecipient{{Address: "archive@mysite.com"}}, subscribers[1].Bcc)
+	assert.True(t, subscribers[1].IsSubscribedTo("jazz"), "Subscribers without profiles receive all of them")
+
+	assert.Equal(t, "jazz@mysite.com", subscribers[2].Address)
+	assert.Equal(t, []string{"jazz"}, subscribers[2].Profiles)
+}
+
+func Test_Parse_InvalidSubscribers(t *testing.T) {
+	testcases := map[string]struct {
+		Config   []byte
+		Expected string
+	}{
+		"Missing address": {
+			Config: []byte(`email:
+  subscribers:
+    - name: "Nobody"`),
+			Expected: "subscriber Nobody has no address",
+		},
+		"Unknown format": {
+			Config: []byte(`email:
+  subscribers:
+    - address: "me@mysite.com"
+      format: "pdf"`),
+			Expected: "unknown format of subscriber me@mysite.com: pdf",
+		},
+		"Unknown profile": {
+			Config: []byte(`title: "metal"
+email:
+  subscribers:
+    - address: "me@mysite.com"
+      profiles: ["jazz"]`),
+			Expected: "subscriber me@mysite.com is subscribed to unknown profile: jazz",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		c := Config{}
+		err := c.Parse(testdata.Config)
+
+		require.Error(t, err, testcase)
+		assert.Equal(t, testdata.Expected, err.Error(), testcase)
+	}
+}
+
+func Test_Parse_Smtp(t *testing.T) {
+	c := Config{}
+	err := c.Parse([]byte(`email:
+  enabled: true
+  transport: "smtp"
+  smtp:
+    host: "mail.mysite.com"
+    username: "me"
+    password: "secret"`))
+	require.NoError(t, err, "It should parse the config successfully")
+
+	assert.Equal(t, TransportSmtp, c.Email.Transport)
+	assert.Equal(t, Smtp{
+		Host:     "mail.mysite.com",
+		Port:     587,
+		Username: "me",
+		Password: "secret",
+		Security: SmtpStartTls,
+		Auth:     SmtpAuthPlain,
+	}, c.Email.Smtp, "The defaults should be used for the missing values")
+}
+
+func Test_Parse_InvalidEmail(t *testing.T) {
+	testcases := map[string]struct {
+		Config   []byte
+		Expected string
+	}{
+		"Unknown transport": {
+			Config: []byte(`email:
+  transport: "pigeon"`),
+			Expected: "unknown email transport: pigeon",
+		},
+		"Missing host": {
+			Config: []byte(`email:
+  enabled: true
+  transport: "smtp"`),
+			Expected: "the smtp transport requires a host",
+		},
+		"Unknown security": {
+			Config: []byte(`email:
+  transport: "smtp"
+  smtp:
+    security: "ssl"`),
+			Expected: "unknown smtp security: ssl",
+		},
+		"Unknown auth": {
+			Config: []byte(`email:
+  transport: "smtp"
+  smtp:
+    auth: "cram-md5"`),
+			Expected: "unknown smtp auth: cram-md5",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		c := Config{}
+		err := c.Parse(testdata.Config)
+
+		require.Error(t, err, testcase)
+		assert.Equal(t, testdata.Expected, err.Error(), testcase)
+	}
+}
+
+func Test_Parse_Notifiers(t *testing.T) {
+	c := Config{}
+	err := c.Parse([]byte(`title: "metal"
+notifiers:
+  slack:
+    - url: "https://hooks.slack.com/services/abc"
+      top: 3
+  discord:
+    - url: "https://discord.com/api/webhooks/abc"
+      profiles: ["metal"]
+  matrix:
+    - homeserver: "https://matrix.org"
+      room_id: "!abcdef:matrix.org"
+      access_token: "secret"
+  webhooks:
+    - url: "https://hooks.mysite.com/music"
+      secret: "secret"
+  push:
+    - service: "ntfy"
+      url: "https://ntfy.sh"
+      topic: "music"
+      reports_url: "https://music.mysite.com"`))
+	require.NoError(t, err, "It should parse the config successfully")
+
+	assert.Equal(t, []ChatWebhook{{Url: "https://hooks.slack.com/services/abc", Channel: Channel{Top: 3}}}, c.Notifiers.Slack)
+	assert.True(t, c.Notifiers.Discord[0].IsSubscribedTo("metal"))
+	assert.False(t, c.Notifiers.Discord[0].IsSubscribedTo("jazz"))
+	assert.Equal(t, "!abcdef:matrix.org", c.Notifiers.Matrix[0].RoomId)
+	assert.Equal(t, []Webhook{{Url: "https://hooks.mysite.com/music", Secret: "secret"}}, c.Notifiers.Webhooks)
+	assert.Equal(t, []Push{{Service: PushNtfy, Url: "https://ntfy.sh", Topic: "music", ReportsUrl: "https://music.mysite.com"}}, c.Notifiers.Push)
+	assert.Equal(t, 5*time.Second, c.Notifiers.Retry.MaxBackoff, "The webhooks should have their own retry defaults")
+}
+
+func Test_Parse_InvalidNotifiers(t *testing.T) {
+	testcases := map[string]struct {
+		Config   []byte
+		Expected string
+	}{
+		"Missing url": {
+			Config: []byte(`notifiers:
+  discord:
+    - top: 3`),
+			Expected: "discord notifier has no url",
+		},
+		"Unknown profile": {
+			Config: []byte(`title: "metal"
+notifiers:
+  slack:
+    - url: "https://hooks.slack.com/services/abc"
+      profiles: ["jazz"]`),
+			Expected: "slack notifier is subscribed to unknown profile: jazz",
+		},
+		"Incomplete matrix room": {
+			Config: []byte(`notifiers:
+  matrix:
+    - homeserver: "https://matrix.org"`),
+			Expected: "matrix notifier requires a homeserver, room_id and access_token",
+		},
+		"Unknown push service": {
+			Config: []byte(`notifiers:
+  push:
+    - service: "pushover"
+      url: "https://api.pushover.net"`),
+			Expected: "unknown push service: pushover",
+		},
+		"Ntfy without topic": {
+			Config: []byte(`notifiers:
+  push:
+    - service: "ntfy"
+      url: "https://ntfy.sh"`),
+			Expected: "ntfy notifier requires a topic",
+		},
+		"Gotify without token": {
+			Config: []byte(`notifiers:
+  push:
+    - service: "gotify"
+      url: "https://gotify.mysite.com"`),
+			Expected: "gotify notifier requires a token",
+		},
+		"Webhook without url": {
+			Config: []byte(`notifiers:
+  webhooks:
+    - secret: "secret"`),
+			Expected: "webhook notifier has no url",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		c := Config{}
+		err := c.Parse(testdata.Config)
+
+		require.Error(t, err, testcase)
+		assert.Equal(t, testdata.Expected, err.Error(), testcase)
+	}
+}
+
+func Test_Parse_Feed(t *testing.T) {
+	testcases := map[string]struct {
+		Config   []byte
+		Expected string
+	}{
+		"Valid": {
+			Config: []byte(`history:
+  enabled: true
+feed:
+  enabled: true`),
+		},
+		"Without history": {
+			Config: []byte(`history:
+  enabled: false
+feed:
+  enabled: true`),
+			Expected: "the feed requires the history to be enabled",
+		},
+		"No weeks": {
+			Config: []byte(`history:
+  enabled: true
+feed:
+  enabled: true
+  weeks: 0`),
+			Expected: "the feed must contain at least one week",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		c := Config{}
+		err := c.Parse(testdata.Config)
+
+		if testdata.Expected == "" {
+			require.NoError(t, err, testcase)
+			assert.Equal(t, 8, c.Feed.Weeks, testcase+": the default should be used")
+			continue
+		}
+		require.Error(t, err, testcase)
+		assert.Equal(t, testdata.Expected, err.Error(), testcase)
+	}
+}
+
+func Test_Parse_InvalidRequests(t *testing.T) {
+	testcases := map[string]struct {
+		Config   []byte
+		Expected string
+	}{
+		"Rate limit without burst": {
+			Config: []byte(`rate_limit:
+  requests_per_second: 2
+  burst: 0`),
+			Expected: "the rate_limit burst must be at least 1",
+		},
+		"Retry without backoff": {
+			Config: []byte(`retry:
+  max_attempts: 3
+  max_backoff: 0s`),
+			Expected: "the retry max_backoff must be greater than zero when requests are retried",
+		},
+		"Webhook retry without backoff": {
+			Config: []byte(`notifiers:
+  retry:
+    max_attempts: 3
+    max_backoff: 0s`),
+			Expected: "notifiers: the retry max_backoff must be greater than zero when requests are retried",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		c := Config{}
+		err := c.Parse(testdata.Config)
+
+		require.Error(t, err, testcase)
+		assert.Equal(t, testdata.Expected, err.Error(), testcase)
+	}
+
+	//the rate limit and retries can still be turned off
+	c := Config{}
+	err := c.Parse([]byte(`rate_limit:
+  requests_per_second: 0
+  burst: 0
+retry:
+  max_attempts: 1
+  max_backoff: 0s`))
+	assert.NoError(t, err)
+}
+
+func Test_Parse_InvalidScoringStrategy(t *testing.T) {
+	c := Config{}
+	err := c.Parse([]byte(`scoring:
+  strategy: "vibes"`))
+
+	assert.Error(t, err)
 }
 
 func Test_IsInterestingMainGenre(t *testing.T) {
@@ -78,7 +622,7 @@ func Test_IsInterestingMainGenre(t *testing.T) {
 	}
 
 	for testcase, testdata := range testcases {
-		c := Config{MainGenres: testdata.List}
+		c := Profile{MainGenres: testdata.List}
 		res := c.IsInterestingMainGenre(testdata.Genre)
 		assert.Equal(t, testdata.Expected, res, testcase)
 	}
@@ -138,3 +682,94 @@ func Test_IsInterestingSubGenre(t *testing.T) {
 		assert.Equal(t, testdata.Expected, res, testcase)
 	}
 }
+
+func Test_Parse_Exclusions(t *testing.T) {
+	//given
+	testConfig := []byte(`main_genres:
+  - "Rock"
+main_genre_exclusions:
+  exclude_exact:
+    - "Pop/Rock"
+sub_genres:
+  fuzzy_matches:
+    - "Metal"
+  exclude_fuzzy:
+    - "Christian"
+  exclude_exact:
+    - "Pop-Metal"`)
+
+	//when
+	c := Config{}
+	err := c.Parse(testConfig)
+
+	//then
+	require.NoError(t, err, "It should parse the config successfully")
+	assert.Equal(t, []string{"Pop/Rock"}, c.MainGenreExclusions.ExcludeExact)
+	assert.Equal(t, []string{"Metal"}, c.SubGenres.FuzzyMatches)
+	assert.Equal(t, []string{"Christian"}, c.SubGenres.ExcludeFuzzy)
+	assert.Equal(t, []string{"Pop-Metal"}, c.SubGenres.ExcludeExact)
+}
+
+func Test_IsExcludedGenre(t *testing.T) {
+	testcases := map[string]struct {
+		FuzzyList []string
+		ExactList []string
+		Genre     string
+		Expected  bool
+	}{
+		"No match": {
+			FuzzyList: []string{"Christian"},
+			ExactList: []string{"Pop-Metal"},
+			Genre:     "Heavy Metal",
+			Expected:  false,
+		},
+		"Fuzzy match": {
+			FuzzyList: []string{"Christian"},
+			ExactList: []string{"Pop-Metal"},
+			Genre:     "Christian Metal",
+			Expected:  true,
+		},
+		"Exact match": {
+			FuzzyList: []string{"Christian"},
+			ExactList: []string{"Pop-Metal"},
+			Genre:     "Pop-Metal",
+			Expected:  true,
+		},
+		"Fuzzy match of exact list": {
+			FuzzyList: []string{"Christian"},
+			ExactList: []string{"Pop-Metal"},
+			Genre:     "Pop-Metal Revival",
+			Expected:  false,
+		},
+		"Empty lists": {
+			FuzzyList: []string{},
+			ExactList: []string{},
+			Genre:     "Christian Metal",
+			Expected:  false,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		exclusions := GenreExclusions{ExcludeFuzzy: testdata.FuzzyList, ExcludeExact: testdata.ExactList}
+
+		c := Profile{MainGenreExclusions: exclusions}
+		c.SubGenres.GenreExclusions = exclusions
+
+		assert.Equal(t, testdata.Expected, c.IsExcludedMainGenre(testdata.Genre), testcase)
+		assert.Equal(t, testdata.Expected, c.IsExcludedSubGenre(testdata.Genre), testcase)
+	}
+}
+
+func Test_IsAlwaysAndNeverIncluded(t *testing.T) {
+	c := Profile{
+		AlwaysInclude: []string{"King Diamond", "https://www.allmusic.com/artist/mercyful-fate-mn0000384127/"},
+		NeverInclude:  []string{"nickelback"},
+	}
+
+	assert.True(t, c.IsAlwaysIncluded("king diamond", ""), "Names should match ignoring case")
+	assert.True(t, c.IsAlwaysIncluded("", "https://www.allmusic.com/artist/mercyful-fate-mn0000384127"), "Links should match")
+	assert.False(t, c.IsAlwaysIncluded("Manowar", "https://www.allmusic.com/artist/manowar-mn0000498591"))
+	assert.False(t, c.IsAlwaysIncluded("", ""), "Empty values should not match")
+	assert.True(t, c.IsNeverIncluded("Nickelback", "https://www.allmusic.com/artist/nickelback-mn0000049415"))
+	assert.False(t, c.IsNeverIncluded("King Diamond", ""))
+}
@@ -0,0 +1,147 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif" //decoders for the image formats which allmusic uses
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ynori7/music/allmusic"
+	"golang.org/x/image/draw"
+)
+
+const maxImageSize = 5 << 20 //5 MB
+
+// InlineImage is an image which is attached to the email and referenced by its content id
+type InlineImage struct {
+	ContentID   string
+	ContentType string
+	Filename    string
+	Data        []byte
+}
+
+// ImageEmbedder downloads the images of the reports and shrinks them so they can be attached to the emails.
+// Each image is only downloaded once per run, even when it's sent to several subscribers.
+type ImageEmbedder struct {
+	httpClient allmusic.HttpClient
+	size       int
+
+	mutex  *sync.Mutex
+	images map[string]*InlineImage //nil when the image couldn't be downloaded
+}
+
+func NewImageEmbedder(httpClient allmusic.HttpClient, size int) ImageEmbedder {
+	return ImageEmbedder{
+		httpClient: httpClient,
+		size:       size,
+		mutex:      &sync.Mutex{},
+		images:     make(map[string]*InlineImage),
+	}
+}
+
+// Embed returns the content ids of the images by url and the images which need to be attached.
+// Images which can't be downloaded are left out, so they're still linked.
+func (e ImageEmbedder) Embed(ctx context.Context, urls []string) (map[string]string, []InlineImage) {
+	contentIDs := make(map[string]string)
+	images := make([]InlineImage, 0, len(urls))
+	for _, url := range urls {
+		if img := e.getImage(ctx, url); img != nil {
+			contentIDs[url] = img.ContentID
+			images = append(images, *img)
+		}
+	}
+	return contentIDs, images
+}
+
+func (e ImageEmbedder) getImage(ctx context.Context, url string) *InlineImage {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if img, ok := e.images[url]; ok {
+		return img
+	}
+
+	img, err := e.download(ctx, url)
+	if err != nil {
+		log.WithFields(log.Fields{"Logger": "ImageEmbedder", "error": err, "Url": url}).Warn("Error downloading image, it stays linked")
+		if ctx.Err() != nil {
+			return nil //try again with the next context
+		}
+	}
+	e.images[url] = img
+	return img
+}
+
+func (e ImageEmbedder) download(ctx context.Context, url string) (*InlineImage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxImageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	data, format, err := shrinkImage(data, e.size)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(url))
+	id := hex.EncodeToString(hash[:8])
+	return &InlineImage{
+		ContentID:   id + "@music",
+		ContentType: "image/" + format,
+		Filename:    id + "." + format,
+		Data:        data,
+	}, nil
+}
+
+// shrinkImage scales the image down to the given width if it's wider. Images which are small enough are kept as they are,
+// except for gifs which are converted to png. It returns the image and its format.
+func shrinkImage(data []byte, width int) ([]byte, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() <= width && format != "gif" {
+		return data, format, nil
+	}
+
+	if bounds.Dx() > width {
+		height := bounds.Dy() * width / bounds.Dx()
+		scaled := image.NewRGBA(image.Rect(0, 0, width, max(height, 1)))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Over, nil)
+		img = scaled
+	}
+
+	var b bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&b, img, &jpeg.Options{Quality: 85})
+	} else {
+		format = "png" //keeps the transparency of the rating images
+		err = png.Encode(&b, img)
+	}
+	return b.Bytes(), format, err
+}
@@ -0,0 +1,78 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTestImage(t *testing.T, width, height int, format string) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+
+	var b bytes.Buffer
+	if format == "png" {
+		require.NoError(t, png.Encode(&b, img))
+	} else {
+		require.NoError(t, jpeg.Encode(&b, img, nil))
+	}
+	return b.Bytes()
+}
+
+func Test_ImageEmbedder_Embed(t *testing.T) {
+	//given
+	cover := encodeTestImage(t, 400, 300, "jpeg")
+	rating := encodeTestImage(t, 80, 16, "png")
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		switch req.URL.Path {
+		case "/cover.jpg":
+			rw.Write(cover)
+		case "/rating.png":
+			rw.Write(rating)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	embedder := NewImageEmbedder(server.Client(), 192)
+	urls := []string{server.URL + "/cover.jpg", server.URL + "/rating.png", server.URL + "/missing.jpg"}
+
+	//when
+	contentIDs, images := embedder.Embed(context.Background(), urls)
+	_, again := embedder.Embed(context.Background(), urls)
+
+	//then
+	require.Len(t, images, 2, "The missing image should stay linked")
+	assert.Len(t, contentIDs, 2)
+	assert.NotContains(t, contentIDs, server.URL+"/missing.jpg")
+	assert.Equal(t, images, again)
+	assert.Equal(t, int32(3), requests.Load(), "Every image should only be downloaded once")
+
+	assert.Equal(t, contentIDs[urls[0]], images[0].ContentID)
+	assert.Equal(t, "image/jpeg", images[0].ContentType)
+	resized, _, err := image.DecodeConfig(bytes.NewReader(images[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, 192, resized.Width, "The cover should be shrunk")
+	assert.Equal(t, 144, resized.Height, "The aspect ratio should be kept")
+
+	assert.Equal(t, "image/png", images[1].ContentType)
+	assert.Equal(t, rating, images[1].Data, "Small images should be kept as they are")
+}
@@ -1,41 +1,62 @@
 package email
 
 import (
-	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"context"
+
 	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/view"
 )
 
+// Transport delivers the emails. The sending is aborted when the context is done.
+type Transport interface {
+	Send(ctx context.Context, from config.EmailRecipient, message Message) error
+}
+
 type Mailer struct {
-	config      config.Config
-	emailClient *mailjet.Client
+	config        config.Config
+	transport     Transport
+	imageEmbedder *ImageEmbedder //optional
+	templates     view.Templates
+}
+
+// Message is an email with a plain text body and optionally an HTML alternative
+type Message struct {
+	To       []config.EmailRecipient
+	Cc       []config.EmailRecipient
+	Bcc      []config.EmailRecipient
+	Subject  string
+	HtmlBody string
+	TextBody string
+	Images   []InlineImage //the images which the HTML body references by content id
 }
 
 func NewMailer(conf config.Config) Mailer {
+	var transport Transport
+	switch conf.Email.Transport {
+	case config.TransportSmtp:
+		transport = NewSmtpTransport(conf.Email.Smtp)
+	default:
+		transport = NewMailjetTransport(conf.Email.PublicKey, conf.Email.PrivateKey)
+	}
+
 	return Mailer{
-		config:      conf,
-		emailClient: mailjet.NewMailjetClient(conf.Email.PublicKey, conf.Email.PrivateKey),
+		config:    conf,
+		transport: transport,
 	}
 }
 
-func (m Mailer) SendMail(subject string, htmlBody string) error {
-	messagesInfo := []mailjet.InfoMessagesV31{
-		{
-			From: &mailjet.RecipientV31{
-				Email: m.config.Email.From.Address,
-				Name:  m.config.Email.From.Name,
-			},
-			To: &mailjet.RecipientsV31{
-				mailjet.RecipientV31{
-					Email: m.config.Email.To.Address,
-					Name:  m.config.Email.To.Name,
-				},
-			},
-			Subject:  subject,
-			HTMLPart: htmlBody,
-		},
-	}
-	messages := mailjet.MessagesV31{Info: messagesInfo}
-	_, err := m.emailClient.SendMailV31(&messages)
+// WithImageEmbedder attaches the images of the HTML reports instead of linking them
+func (m Mailer) WithImageEmbedder(imageEmbedder ImageEmbedder) Mailer {
+	m.imageEmbedder = &imageEmbedder
+	return m
+}
+
+// WithTemplates renders the reports with custom templates instead of the built-in ones
+func (m Mailer) WithTemplates(templates view.Templates) Mailer {
+	m.templates = templates
+	return m
+}
 
-	return err
+func (m Mailer) SendMail(ctx context.Context, message Message) error {
+	return m.transport.Send(ctx, m.config.Email.From, message)
 }
@@ -0,0 +1,74 @@
+package email
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/ynori7/music/config"
+)
+
+// MailjetTransport sends the emails with the Mailjet API
+type MailjetTransport struct {
+	emailClient *mailjet.Client
+}
+
+func NewMailjetTransport(publicKey, privateKey string) MailjetTransport {
+	return MailjetTransport{
+		emailClient: mailjet.NewMailjetClient(publicKey, privateKey),
+	}
+}
+
+func (t MailjetTransport) Send(ctx context.Context, from config.EmailRecipient, message Message) error {
+	messagesInfo := []mailjet.InfoMessagesV31{
+		{
+			From: &mailjet.RecipientV31{
+				Email: from.Address,
+				Name:  from.Name,
+			},
+			To:       toRecipients(message.To),
+			Cc:       toRecipients(message.Cc),
+			Bcc:      toRecipients(message.Bcc),
+			Subject:  message.Subject,
+			HTMLPart: message.HtmlBody,
+			TextPart: message.TextBody,
+		},
+	}
+	if len(message.Images) > 0 {
+		messagesInfo[0].InlinedAttachments = toInlinedAttachments(message.Images)
+	}
+	messages := mailjet.MessagesV31{Info: messagesInfo}
+	_, err := t.emailClient.SendMailV31(&messages, mailjet.WithContext(ctx))
+
+	return err
+}
+
+func toRecipients(recipients []config.EmailRecipient) *mailjet.RecipientsV31 {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	converted := make(mailjet.RecipientsV31, 0, len(recipients))
+	for _, recipient := range recipients {
+		converted = append(converted, mailjet.RecipientV31{
+			Email: recipient.Address,
+			Name:  recipient.Name,
+		})
+	}
+	return &converted
+}
+
+func toInlinedAttachments(images []InlineImage) *mailjet.InlinedAttachmentsV31 {
+	attachments := make(mailjet.InlinedAttachmentsV31, 0, len(images))
+	for _, img := range images {
+		attachments = append(attachments, mailjet.InlinedAttachmentV31{
+			AttachmentV31: mailjet.AttachmentV31{
+				ContentType:   img.ContentType,
+				Filename:      img.Filename,
+				Base64Content: base64.StdEncoding.EncodeToString(img.Data),
+			},
+			ContentID: img.ContentID,
+		})
+	}
+	return &attachments
+}
@@ -0,0 +1,138 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ynori7/music/config"
+)
+
+// mimeEntity is a part of the message with its headers and a function which writes the encoded body
+type mimeEntity struct {
+	header textproto.MIMEHeader
+	write  func(w io.Writer)
+}
+
+// buildMimeMessage builds the raw email which is sent to SMTP servers. The Bcc recipients are not included.
+func buildMimeMessage(from config.EmailRecipient, message Message) []byte {
+	var b bytes.Buffer
+
+	writeHeader(&b, "From", formatAddresses([]config.EmailRecipient{from}))
+	writeHeader(&b, "To", formatAddresses(message.To))
+	if len(message.Cc) > 0 {
+		writeHeader(&b, "Cc", formatAddresses(message.Cc))
+	}
+	writeHeader(&b, "Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	writeHeader(&b, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&b, "MIME-Version", "1.0")
+
+	body := buildBody(message)
+	keys := make([]string, 0, len(body.header))
+	for key := range body.header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeHeader(&b, key, body.header.Get(key))
+	}
+	b.WriteString("\r\n")
+	body.write(&b)
+
+	return b.Bytes()
+}
+
+// buildBody nests the parts of the message. The HTML is bundled with its inline images, and the parts are
+// ordered from the plainest to the richest because clients show the last one they support.
+func buildBody(message Message) mimeEntity {
+	if message.HtmlBody == "" {
+		return textEntity("text/plain", message.TextBody)
+	}
+
+	html := textEntity("text/html", message.HtmlBody)
+	if len(message.Images) > 0 {
+		parts := []mimeEntity{html}
+		for _, img := range message.Images {
+			parts = append(parts, imageEntity(img))
+		}
+		html = multipartEntity("related", parts...)
+	}
+
+	if message.TextBody == "" {
+		return html
+	}
+	return multipartEntity("alternative", textEntity("text/plain", message.TextBody), html)
+}
+
+func textEntity(contentType, body string) mimeEntity {
+	return mimeEntity{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		write: func(w io.Writer) {
+			qw := quotedprintable.NewWriter(w)
+			qw.Write([]byte(body))
+			qw.Close()
+		},
+	}
+}
+
+func imageEntity(img InlineImage) mimeEntity {
+	return mimeEntity{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {img.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Id":                {"<" + img.ContentID + ">"},
+			"Content-Disposition":       {mime.FormatMediaType("inline", map[string]string{"filename": img.Filename})},
+		},
+		write: func(w io.Writer) {
+			//base64 lines must not be longer than 76 characters
+			encoded := base64.StdEncoding.EncodeToString(img.Data)
+			for len(encoded) > 76 {
+				io.WriteString(w, encoded[:76]+"\r\n")
+				encoded = encoded[76:]
+			}
+			io.WriteString(w, encoded)
+		},
+	}
+}
+
+func multipartEntity(subtype string, parts ...mimeEntity) mimeEntity {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	return mimeEntity{
+		header: textproto.MIMEHeader{
+			"Content-Type": {mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": boundary})},
+		},
+		write: func(w io.Writer) {
+			mw := multipart.NewWriter(w)
+			mw.SetBoundary(boundary)
+			for _, part := range parts {
+				pw, _ := mw.CreatePart(part.header)
+				part.write(pw)
+			}
+			mw.Close()
+		},
+	}
+}
+
+func writeHeader(b *bytes.Buffer, name, value string) {
+	fmt.Fprintf(b, "%s: %s\r\n", name, value)
+}
+
+func formatAddresses(recipients []config.EmailRecipient) string {
+	addresses := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		addresses = append(addresses, (&mail.Address{Name: recipient.Name, Address: recipient.Address}).String())
+	}
+	return strings.Join(addresses, ", ")
+}
@@ -0,0 +1,150 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/ynori7/music/config"
+)
+
+const smtpTimeout = 30 * time.Second //unless the context has a deadline
+
+// SmtpTransport sends the emails with an SMTP server
+type SmtpTransport struct {
+	conf      config.Smtp
+	tlsConfig *tls.Config
+}
+
+func NewSmtpTransport(conf config.Smtp) SmtpTransport {
+	return SmtpTransport{
+		conf:      conf,
+		tlsConfig: &tls.Config{ServerName: conf.Host},
+	}
+}
+
+func (t SmtpTransport) Send(ctx context.Context, from config.EmailRecipient, message Message) error {
+	client, err := t.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if t.conf.Username != "" {
+		if err := client.Auth(t.auth()); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipients := range [][]config.EmailRecipient{message.To, message.Cc, message.Bcc} {
+		for _, recipient := range recipients {
+			if err := client.Rcpt(recipient.Address); err != nil {
+				return fmt.Errorf("recipient %s was rejected: %w", recipient.Address, err)
+			}
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMimeMessage(from, message)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// connect opens the connection to the SMTP server and secures it as configured. The connection ends with the context.
+func (t SmtpTransport) connect(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(t.conf.Host, strconv.Itoa(t.conf.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var conn net.Conn
+	var err error
+	if t.conf.Security == config.SmtpTls {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: t.tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	conn.SetDeadline(deadline)
+	context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now()) //unblocks the pending reads and writes when the context is canceled
+	})
+
+	client, err := smtp.NewClient(conn, t.conf.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if t.conf.Security == config.SmtpStartTls {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("the smtp server doesn't support STARTTLS")
+		}
+		if err := client.StartTLS(t.tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (t SmtpTransport) auth() smtp.Auth {
+	if t.conf.Auth == config.SmtpAuthLogin {
+		return loginAuth{username: t.conf.Username, password: t.conf.Password}
+	}
+	return smtp.PlainAuth("", t.conf.Username, t.conf.Password, t.conf.Host)
+}
+
+// loginAuth implements the LOGIN mechanism which some servers require instead of PLAIN
+type loginAuth struct {
+	username string
+	password string
+}
+
+func (a loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch string(fromServer) {
+	case "Username:":
+		return []byte(a.username), nil
+	case "Password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
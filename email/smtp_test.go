@@ -0,0 +1,429 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/config"
+)
+
+// fakeSmtpServer is a minimal SMTP server which records what it receives
+type fakeSmtpServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config //STARTTLS is offered when set
+	username  string
+	password  string
+
+	mutex      sync.Mutex
+	secured    bool
+	authorized bool
+	from       string
+	recipients []string
+	data       string
+}
+
+func newFakeSmtpServer(t *testing.T, implicitTls bool, tlsConfig *tls.Config) *fakeSmtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "There was an error starting the smtp server")
+	if implicitTls {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	server := &fakeSmtpServer{listener: listener, tlsConfig: tlsConfig, username: "user", password: "secret", secured: implicitTls}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (s *fakeSmtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSmtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			s.mutex.Lock()
+			secured := s.secured
+			s.mutex.Unlock()
+			text.PrintfLine("250-localhost")
+			if s.tlsConfig != nil && !secured {
+				text.PrintfLine("250-STARTTLS")
+			}
+			text.PrintfLine("250 AUTH PLAIN LOGIN")
+		case "STARTTLS":
+			text.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			s.mutex.Lock()
+			s.secured = true
+			s.mutex.Unlock()
+		case "AUTH":
+			s.authenticate(text, arg)
+		case "MAIL":
+			s.mutex.Lock()
+			s.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			s.mutex.Unlock()
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			s.mutex.Lock()
+			s.recipients = append(s.recipients, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			s.mutex.Unlock()
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			lines, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.mutex.Lock()
+			s.data = strings.Join(lines, "\n")
+			s.mutex.Unlock()
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func (s *fakeSmtpServer) authenticate(text *textproto.Conn, arg string) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+
+	var username, password string
+	switch mechanism {
+	case "PLAIN":
+		decoded, _ := base64.StdEncoding.DecodeString(initial)
+		parts := strings.Split(string(decoded), "\x00")
+		if len(parts) == 3 {
+			username, password = parts[1], parts[2]
+		}
+	case "LOGIN":
+		username = s.challenge(text, "Username:")
+		password = s.challenge(text, "Password:")
+	}
+
+	if username != s.username || password != s.password {
+		text.PrintfLine("535 Authentication failed")
+		return
+	}
+	s.mutex.Lock()
+	s.authorized = true
+	s.mutex.Unlock()
+	text.PrintfLine("235 Authentication successful")
+}
+
+func (s *fakeSmtpServer) challenge(text *textproto.Conn, prompt string) string {
+	text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+	line, _ := text.ReadLine()
+	decoded, _ := base64.StdEncoding.DecodeString(line)
+	return string(decoded)
+}
+
+// newTestCertificate creates a self-signed certificate for 127.0.0.1 and returns the server and client tls configs
+func newTestCertificate(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	clientConfig := &tls.Config{ServerName: "127.0.0.1", RootCAs: pool}
+	return serverConfig, clientConfig
+}
+
+func Test_SmtpTransport_Send(t *testing.T) {
+	serverTls, clientTls := newTestCertificate(t)
+
+	testcases := map[string]struct {
+		ImplicitTls bool
+		ServerTls   *tls.Config
+		Security    string
+		Auth        string
+	}{
+		"STARTTLS with PLAIN": {
+			ServerTls: serverTls,
+			Security:  config.SmtpStartTls,
+			Auth:      config.SmtpAuthPlain,
+		},
+		"Implicit TLS with LOGIN": {
+			ImplicitTls: true,
+			ServerTls:   serverTls,
+			Security:    config.SmtpTls,
+			Auth:        config.SmtpAuthLogin,
+		},
+		"Unencrypted local server": {
+			Security: config.SmtpNone,
+			Auth:     config.SmtpAuthPlain,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		//given
+		server := newFakeSmtpServer(t, testdata.ImplicitTls, testdata.ServerTls)
+		transport := NewSmtpTransport(config.Smtp{
+			Host:     "127.0.0.1",
+			Port:     server.port(),
+			Username: "user",
+			Password: "secret",
+			Security: testdata.Security,
+			Auth:     testdata.Auth,
+		})
+		transport.tlsConfig = clientTls
+
+		message := Message{
+			To:       []config.EmailRecipient{{Address: "me@mysite.com", Name: "Me"}},
+			Cc:       []config.EmailRecipient{{Address: "friend@mysite.com"}},
+			Bcc:      []config.EmailRecipient{{Address: "archive@mysite.com"}},
+			Subject:  "Newest releases from the week of 2020-03-27",
+			HtmlBody: "<p>King Diamond</p>",
+		}
+
+		//when
+		err := transport.Send(context.Background(), config.EmailRecipient{Address: "no-reply@mysite.com", Name: "Music"}, message)
+
+		//then
+		require.NoError(t, err, testcase)
+		server.mutex.Lock()
+		assert.Equal(t, testdata.Security != config.SmtpNone, server.secured, testcase)
+		assert.True(t, server.authorized, testcase)
+		assert.Equal(t, "no-reply@mysite.com", server.from, testcase)
+		assert.Equal(t, []string{"me@mysite.com", "friend@mysite.com", "archive@mysite.com"}, server.recipients, testcase)
+		assert.Contains(t, server.data, `To: "Me" <me@mysite.com>`, testcase)
+		assert.Contains(t, server.data, "Cc: <friend@mysite.com>", testcase)
+		assert.NotContains(t, server.data, "archive@mysite.com", testcase+": bcc recipients must not be visible")
+		assert.Contains(t, server.data, "Content-Type: text/html; charset=utf-8", testcase)
+		assert.Contains(t, server.data, "<p>King Diamond</p>", testcase)
+		server.mutex.Unlock()
+	}
+}
+
+func Test_SmtpTransport_Send_Errors(t *testing.T) {
+	testcases := map[string]struct {
+		Security string
+		Password string
+		Expected string
+	}{
+		"Wrong password": {
+			Security: config.SmtpNone,
+			Password: "wrong",
+			Expected: "smtp authentication failed",
+		},
+		"STARTTLS not supported": {
+			Security: config.SmtpStartTls,
+			Password: "secret",
+			Expected: "doesn't support STARTTLS",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		//given
+		server := newFakeSmtpServer(t, false, nil)
+		transport := NewSmtpTransport(config.Smtp{
+			Host:     "127.0.0.1",
+			Port:     server.port(),
+			Username: "user",
+			Password: testdata.Password,
+			Security: testdata.Security,
+			Auth:     config.SmtpAuthPlain,
+		})
+
+		//when
+		err := transport.Send(context.Background(), config.EmailRecipient{Address: "no-reply@mysite.com"}, Message{
+			To:       []config.EmailRecipient{{Address: "me@mysite.com"}},
+			TextBody: "King Diamond",
+		})
+
+		//then
+		require.Error(t, err, testcase)
+		assert.Contains(t, err.Error(), testdata.Expected, testcase)
+	}
+}
+
+func Test_SmtpTransport_Send_Timeout(t *testing.T) {
+	//given
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn) //accepts the connection but never greets the client
+	}()
+
+	transport := NewSmtpTransport(config.Smtp{
+		Host:     "127.0.0.1",
+		Port:     listener.Addr().(*net.TCPAddr).Port,
+		Security: config.SmtpNone,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	//when
+	start := time.Now()
+	err = transport.Send(ctx, config.EmailRecipient{Address: "no-reply@mysite.com"}, Message{
+		To:       []config.EmailRecipient{{Address: "me@mysite.com"}},
+		TextBody: "King Diamond",
+	})
+
+	//then
+	assert.Error(t, err, "The server never answered")
+	assert.Less(t, time.Since(start), 5*time.Second, "The send should end with the context")
+}
+
+func Test_buildMimeMessage(t *testing.T) {
+	//when
+	raw := string(buildMimeMessage(config.EmailRecipient{Address: "no-reply@mysite.com"}, Message{
+		To:       []config.EmailRecipient{{Address: "me@mysite.com"}},
+		Subject:  "Neueste Veröffentlichungen",
+		TextBody: "Motörhead - " + strings.Repeat("x", 100),
+	}))
+
+	//then
+	headers, body, found := strings.Cut(raw, "\r\n\r\n")
+	require.True(t, found, "The headers should be separated from the body")
+	assert.Contains(t, headers, "Subject: =?utf-8?q?Neueste_Ver=C3=B6ffentlichungen?=")
+	assert.Contains(t, headers, "Content-Type: text/plain; charset=utf-8")
+	assert.NotContains(t, headers, "Cc:")
+	assert.Contains(t, body, "Mot=C3=B6rhead")
+	for _, line := range strings.Split(body, "\r\n") {
+		assert.LessOrEqual(t, len(line), 76, "Quoted-printable lines must not be longer than 76 characters")
+	}
+}
+
+func Test_buildMimeMessage_Multipart(t *testing.T) {
+	//when
+	raw := buildMimeMessage(config.EmailRecipient{Address: "no-reply@mysite.com"}, Message{
+		To:       []config.EmailRecipient{{Address: "me@mysite.com"}},
+		Subject:  "Newest releases from the week of 2020-03-27",
+		TextBody: "1. King Diamond - Give Me Your Soul... Please",
+		HtmlBody: "<p>King Diamond</p>",
+	})
+
+	//then
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err, "The message should be parseable")
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	expected := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "1. King Diamond - Give Me Your Soul... Please"},
+		{"text/html; charset=utf-8", "<p>King Diamond</p>"},
+	}
+	for _, e := range expected {
+		part, err := reader.NextPart()
+		require.NoError(t, err, "There should be a %s part", e.contentType)
+		assert.Equal(t, e.contentType, part.Header.Get("Content-Type"))
+		body, err := io.ReadAll(part) //the quoted-printable encoding is removed by the reader
+		require.NoError(t, err)
+		assert.Equal(t, e.body, string(body))
+	}
+	_, err = reader.NextPart()
+	assert.Equal(t, io.EOF, err, "There should only be two parts")
+}
+
+func Test_buildMimeMessage_InlineImages(t *testing.T) {
+	//when
+	raw := buildMimeMessage(config.EmailRecipient{Address: "no-reply@mysite.com"}, Message{
+		To:       []config.EmailRecipient{{Address: "me@mysite.com"}},
+		TextBody: "King Diamond",
+		HtmlBody: `<img src="cid:cover@music">`,
+		Images:   []InlineImage{{ContentID: "cover@music", ContentType: "image/jpeg", Filename: "cover.jpg", Data: bytes.Repeat([]byte{0xff}, 100)}},
+	})
+
+	//then
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err, "The message should be parseable")
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+
+	alternative := multipart.NewReader(msg.Body, params["boundary"])
+	_, err = alternative.NextPart()
+	require.NoError(t, err, "There should be a plain text part")
+	related, err := alternative.NextPart()
+	require.NoError(t, err, "There should be a related part")
+
+	mediaType, params, err := mime.ParseMediaType(related.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/related", mediaType, "The HTML should be bundled with its images")
+
+	parts := multipart.NewReader(related, params["boundary"])
+	html, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", html.Header.Get("Content-Type"))
+
+	img, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "<cover@music>", img.Header.Get("Content-Id"))
+	assert.Equal(t, "inline; filename=cover.jpg", img.Header.Get("Content-Disposition"))
+	encoded, err := io.ReadAll(img)
+	require.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0xff}, 100), decoded)
+}
@@ -0,0 +1,110 @@
+package email
+
+import (
+	"context"
+
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/view"
+)
+
+// Report contains the interesting discographies of a profile which are personalised for each subscriber
+type Report struct {
+	Profile       string
+	From          allmusic.ReleaseWeek
+	To            allmusic.ReleaseWeek
+	Discographies []allmusic.Discography
+}
+
+// Delivery is the outcome of sending a report to a subscriber
+type Delivery struct {
+	Subscriber config.Subscriber
+	Profile    string
+	From       allmusic.ReleaseWeek
+	To         allmusic.ReleaseWeek
+	Subject    string
+	Err        error
+}
+
+// SendToSubscribers sends each subscriber the reports of the profiles they're subscribed to. Each email may take up
+// to the request timeout, so a failed or hanging delivery doesn't stop the others. The outcome of each one is returned.
+func (m Mailer) SendToSubscribers(ctx context.Context, subscribers []config.Subscriber, reports []Report) []Delivery {
+	deliveries := make([]Delivery, 0)
+	for _, subscriber := range subscribers {
+		for _, report := range reports {
+			if !subscriber.IsSubscribedTo(report.Profile) {
+				continue
+			}
+
+			delivery := Delivery{
+				Subscriber: subscriber,
+				Profile:    report.Profile,
+				From:       report.From,
+				To:         report.To,
+				Subject:    m.getSubjectLine(report),
+			}
+			delivery.Err = m.send(ctx, subscriber, delivery.Subject, report.Discographies)
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries
+}
+
+// send builds the subscriber's email, including the download of its images, and sends it within the request timeout
+func (m Mailer) send(ctx context.Context, subscriber config.Subscriber, subject string, discographies []allmusic.Discography) error {
+	if m.config.Timeouts.Request > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.config.Timeouts.Request)
+		defer cancel()
+	}
+
+	message, err := m.buildMessage(ctx, subscriber, subject, discographies)
+	if err != nil {
+		return err
+	}
+	return m.SendMail(ctx, message)
+}
+
+// getSubjectLine includes the title of the profile when there are several of them
+func (m Mailer) getSubjectLine(report Report) string {
+	subject := view.GetBackfillSubjectLine(report.From, report.To)
+	if len(m.config.Profiles) > 0 {
+		subject = report.Profile + ": " + subject
+	}
+	return subject
+}
+
+// buildMessage renders the discographies which meet the subscriber's minimum score in their format
+func (m Mailer) buildMessage(ctx context.Context, subscriber config.Subscriber, subject string, discographies []allmusic.Discography) (Message, error) {
+	message := Message{
+		To:      []config.EmailRecipient{subscriber.EmailRecipient},
+		Cc:      subscriber.Cc,
+		Bcc:     subscriber.Bcc,
+		Subject: subject,
+	}
+
+	personalised := make([]allmusic.Discography, 0, len(discographies))
+	for _, discography := range discographies {
+		if discography.Score >= subscriber.MinScore {
+			personalised = append(personalised, discography)
+		}
+	}
+
+	//The plain text is always included so that text-only clients can show the report
+	var err error
+	if message.TextBody, err = view.NewTextTemplate(personalised).WithTemplates(m.templates).ExecuteTextTemplate(); err != nil {
+		return message, err
+	}
+	if subscriber.Format == config.FormatText {
+		return message, nil
+	}
+
+	template := view.NewHtmlTemplate(personalised).WithTemplates(m.templates)
+	if m.imageEmbedder != nil {
+		var contentIDs map[string]string
+		contentIDs, message.Images = m.imageEmbedder.Embed(ctx, template.ImageUrls())
+		template = template.WithInlineImages(contentIDs)
+	}
+	message.HtmlBody, err = template.ExecuteHtmlTemplate()
+	return message, err
+}
@@ -0,0 +1,162 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+)
+
+func Test_SendToSubscribers(t *testing.T) {
+	//given
+	var mutex sync.Mutex
+	sent := make(map[string]mailjet.InfoMessagesV31)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var messages mailjet.MessagesV31
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&messages), "The request should contain the messages")
+		message := messages.Info[0]
+
+		if (*message.To)[0].Email == "broken@mysite.com" {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(`{"Messages":[{"Status":"error"}]}`))
+			return
+		}
+
+		mutex.Lock()
+		sent[(*message.To)[0].Email] = message
+		mutex.Unlock()
+		rw.Write([]byte(`{"Messages":[{"Status":"success"}]}`))
+	}))
+	defer server.Close()
+
+	conf := config.Config{Profiles: config.Profiles{{Title: "metal"}, {Title: "jazz"}}}
+	conf.Email.From = config.EmailRecipient{Address: "no-reply@mysite.com"}
+	mailer := Mailer{
+		config:    conf,
+		transport: MailjetTransport{emailClient: mailjet.NewMailjetClient("public", "private", server.URL+"/v3")},
+	}
+
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err)
+	reports := []Report{
+		{
+			Profile: "metal",
+			From:    week,
+			To:      week,
+			Discographies: []allmusic.Discography{
+				{Artist: allmusic.Artist{Name: "King Diamond"}, NewestRelease: allmusic.Album{Title: "Give Me Your Soul... Please"}, Score: 90},
+				{Artist: allmusic.Artist{Name: "Ghost"}, NewestRelease: allmusic.Album{Title: "Impera"}, Score: 60},
+			},
+		},
+		{
+			Profile:       "jazz",
+			From:          week,
+			To:            week,
+			Discographies: []allmusic.Discography{{Artist: allmusic.Artist{Name: "Kamasi Washington"}, Score: 80}},
+		},
+	}
+
+	subscribers := []config.Subscriber{
+		{
+			EmailRecipient: config.EmailRecipient{Address: "metalhead@mysite.com"},
+			Profiles:       []string{"metal"},
+			Format:         config.FormatText,
+			MinScore:       70,
+			Cc:             []config.EmailRecipient{{Address: "friend@mysite.com"}},
+		},
+		{
+			EmailRecipient: config.EmailRecipient{Address: "broken@mysite.com"},
+			Profiles:       []string{"jazz"},
+		},
+		{
+			EmailRecipient: config.EmailRecipient{Address: "jazzcat@mysite.com"},
+			Profiles:       []string{"jazz"},
+		},
+	}
+
+	//when
+	deliveries := mailer.SendToSubscribers(context.Background(), subscribers, reports)
+
+	//then
+	require.Len(t, deliveries, 3)
+	assert.NoError(t, deliveries[0].Err)
+	assert.Equal(t, "metal: Newest releases from the week of 2020-03-27", deliveries[0].Subject)
+	assert.Error(t, deliveries[1].Err, "The failed delivery should be reported")
+	assert.Equal(t, "broken@mysite.com", deliveries[1].Subscriber.Address)
+
+	message := sent["metalhead@mysite.com"]
+	assert.Equal(t, "friend@mysite.com", (*message.Cc)[0].Email)
+	assert.Empty(t, message.HTMLPart, "The subscriber wants plain text")
+	assert.Contains(t, message.TextPart, "King Diamond - Give Me Your Soul... Please")
+	assert.NotContains(t, message.TextPart, "Ghost", "Releases below the minimum score should be left out")
+
+	message = sent["jazzcat@mysite.com"]
+	assert.Contains(t, message.HTMLPart, "Kamasi Washington")
+	assert.Contains(t, message.TextPart, "Kamasi Washington", "The plain text alternative should always be included")
+}
+
+// fakeImageClient serves images by url
+type fakeImageClient map[string][]byte
+
+func (f fakeImageClient) Do(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	if data, ok := f[req.URL.String()]; ok {
+		rec.Write(data)
+	} else {
+		rec.WriteHeader(http.StatusNotFound)
+	}
+	return rec.Result(), nil
+}
+
+func Test_SendToSubscribers_InlineImages(t *testing.T) {
+	//given
+	var sent mailjet.InfoMessagesV31
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var messages mailjet.MessagesV31
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&messages), "The request should contain the messages")
+		sent = messages.Info[0]
+		rw.Write([]byte(`{"Messages":[{"Status":"success"}]}`))
+	}))
+	defer server.Close()
+
+	coverUrl := "https://fastly-s3.allmusic.com/release/mr0002744267/front/400/cover.jpg"
+	imageClient := fakeImageClient{coverUrl: encodeTestImage(t, 400, 400, "jpeg")}
+
+	mailer := Mailer{
+		config:    config.Config{},
+		transport: MailjetTransport{emailClient: mailjet.NewMailjetClient("public", "private", server.URL+"/v3")},
+	}.WithImageEmbedder(NewImageEmbedder(imageClient, 192))
+
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err)
+	reports := []Report{{
+		Profile: "metal",
+		From:    week,
+		To:      week,
+		Discographies: []allmusic.Discography{
+			{Artist: allmusic.Artist{Name: "King Diamond"}, NewestRelease: allmusic.Album{Title: "Give Me Your Soul... Please", Image: coverUrl, Rating: 9}},
+		},
+	}}
+
+	//when
+	deliveries := mailer.SendToSubscribers(context.Background(), []config.Subscriber{{EmailRecipient: config.EmailRecipient{Address: "me@mysite.com"}}}, reports)
+
+	//then
+	require.Len(t, deliveries, 1)
+	require.NoError(t, deliveries[0].Err)
+	require.NotNil(t, sent.InlinedAttachments)
+	require.Len(t, *sent.InlinedAttachments, 1, "Only the cover could be downloaded")
+	attachment := (*sent.InlinedAttachments)[0]
+	assert.Equal(t, "image/jpeg", attachment.ContentType)
+	assert.Contains(t, sent.HTMLPart, `src="cid:`+attachment.ContentID+`"`)
+	assert.NotContains(t, sent.HTMLPart, coverUrl)
+	assert.Contains(t, sent.HTMLPart, "https://fastly-gce.allmusic.com/images/newsletter/allmusic-8.png", "The rating image should stay linked")
+}
@@ -4,6 +4,9 @@ import "fmt"
 
 var (
 	ErrNotInterestingGenre  = fmt.Errorf("artist is not an interesting genre")
+	ErrExcludedGenre        = fmt.Errorf("artist has an excluded genre")
 	ErrNotHighEnoughRatings = fmt.Errorf("artist doesn't have high enough ratings")
 	ErrAlbumNotFound        = fmt.Errorf("newest album was not found in the list")
+	ErrRuleNotMatched       = fmt.Errorf("artist doesn't match the filter rule")
+	ErrArtistBlocked        = fmt.Errorf("artist is never included")
 )
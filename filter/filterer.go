@@ -13,20 +13,22 @@ import (
 )
 
 type Filterer struct {
-	conf              config.Config
-	potentialReleases []allmusic.NewRelease
-	discographyClient allmusic.DiscographyClient
+	conf                config.Config
+	potentialReleases   []allmusic.NewRelease
+	discographyProvider allmusic.DiscographyProvider
 }
 
-func NewFilterer(conf config.Config, discographyClient allmusic.DiscographyClient, releases []allmusic.NewRelease) Filterer {
+func NewFilterer(conf config.Config, discographyProvider allmusic.DiscographyProvider, releases []allmusic.NewRelease) Filterer {
 	return Filterer{
-		conf:              conf,
-		potentialReleases: releases,
-		discographyClient: discographyClient,
+		conf:                conf,
+		potentialReleases:   releases,
+		discographyProvider: discographyProvider,
 	}
 }
 
-func (f Filterer) FilterAndEnrich() []allmusic.Discography {
+// FilterAndEnrich looks up the discographies of the potential releases and returns the interesting ones sorted by score.
+// If the context is canceled, the discographies which were found so far are returned.
+func (f Filterer) FilterAndEnrich(ctx context.Context) []allmusic.Discography {
 	logger := log.WithFields(log.Fields{"Logger": "FilterAndEnrich"})
 
 	//Process results
@@ -42,19 +44,24 @@ func (f Filterer) FilterAndEnrich() []allmusic.Discography {
 		func(err error) {
 			unwrappedErr := errors.Unwrap(err)
 			switch unwrappedErr {
-			case ErrAlbumNotFound, ErrNotHighEnoughRatings, ErrNotInterestingGenre:
+			case ErrAlbumNotFound, ErrNotHighEnoughRatings, ErrNotInterestingGenre, ErrExcludedGenre, ErrRuleNotMatched, ErrArtistBlocked:
 				logger.WithFields(log.Fields{"error": err}).Info("Filtered an artist")
 			default:
 				logger.WithFields(log.Fields{"error": err}).Error("Error looking up artist data")
 			}
 		},
-		f.processNewRelease,
+		func(job interface{}) (interface{}, error) {
+			return f.processNewRelease(ctx, job)
+		},
 	)
 
 	//Do the work
-	if err := workerPool.Work(context.Background(), 5, f.potentialReleases); err != nil {
+	if err := workerPool.Work(ctx, 5, f.potentialReleases); err != nil {
 		logger.WithFields(log.Fields{"error": err}).Error("Error processing jobs")
 	}
+	if ctx.Err() != nil {
+		logger.WithFields(log.Fields{"error": ctx.Err()}).Warn("Filtering was interrupted, the results are incomplete")
+	}
 
 	//Sort the results
 	sort.Slice(discographies, func(i, j int) bool {
@@ -64,22 +71,44 @@ func (f Filterer) FilterAndEnrich() []allmusic.Discography {
 	return discographies
 }
 
-func (f Filterer) processNewRelease(job interface{}) (result interface{}, err error) {
+func (f Filterer) processNewRelease(ctx context.Context, job interface{}) (result interface{}, err error) {
 	j := job.(allmusic.NewRelease)
 
-	discography, err := f.discographyClient.GetArtistDiscography(j.ArtistLink)
+	discography, err := f.discographyProvider.GetArtistDiscography(ctx, j.ArtistLink)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", err, j.ArtistLink)
 	}
 
+	//the allowlist and blocklist take precedence over all other checks
+	if f.conf.IsNeverIncluded(discography.Artist.Name, j.ArtistLink) {
+		return nil, fmt.Errorf("%w: %s", ErrArtistBlocked, discography.Artist.Name)
+	}
+	if f.conf.IsAlwaysIncluded(discography.Artist.Name, j.ArtistLink) {
+		return f.processWatchlisted(discography, j)
+	}
+
+	//excluded genres veto the artist regardless of any other genres
+	if excluded := f.findExcludedGenre(discography.Artist.Genres); excluded != "" {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrExcludedGenre, discography.Artist.Name, excluded)
+	}
+
+	if r := f.conf.Rules.ArtistRule(); r != nil {
+		return f.processWithRule(discography, j)
+	}
+
 	//validate genres
-	if !f.artistHasInterestingGenre(discography.Artist.Genres) {
+	genreMatches := f.countInterestingGenres(discography.Artist.Genres)
+	if genreMatches == 0 {
 		return nil, fmt.Errorf("%w: %s", ErrNotInterestingGenre, discography.Artist.Name)
 	}
 
 	//validate ratings
-	if discography.BestRating < 8 {
-		return nil, fmt.Errorf("%w: %s", ErrNotHighEnoughRatings, discography.Artist.Name)
+	ratedAlbums := discography.RatedAlbums()
+	isDebut := ratedAlbums == 0 && f.conf.Thresholds.Debuts.Allowed && genreMatches >= f.conf.Thresholds.Debuts.MinGenreMatches
+	if !isDebut {
+		if err := f.validateRatings(discography, ratedAlbums); err != nil {
+			return nil, err
+		}
 	}
 
 	//filtering out singles and EPs
@@ -88,11 +117,65 @@ func (f Filterer) processNewRelease(job interface{}) (result interface{}, err er
 		return nil, fmt.Errorf("%w: %s - %s", ErrAlbumNotFound, discography.Artist.Name, j.NewAlbumTitle) //it was probably a single or an EP
 	}
 
+	if !isDebut && newestRelease.Rating < f.conf.Thresholds.MinNewestRating {
+		return nil, fmt.Errorf("%w: %s - %s (rated %d)", ErrNotHighEnoughRatings, discography.Artist.Name, newestRelease.Title, newestRelease.Rating)
+	}
+
 	//push the new release
 	discography.NewestRelease = *newestRelease
+	discography.MatchedRules = append(f.matchedNewReleaseRules(j), f.matchedGenres(discography.Artist.Genres)...)
+	if isDebut {
+		discography.MatchedRules = append(discography.MatchedRules, "debut")
+	}
+	return *discography, nil
+}
+
+// processWatchlisted includes the artist regardless of genres and ratings as long as the release is an album
+func (f Filterer) processWatchlisted(discography *allmusic.Discography, release allmusic.NewRelease) (interface{}, error) {
+	newestRelease := f.findNewRelease(discography, release.NewAlbumTitle)
+	if newestRelease == nil {
+		return nil, fmt.Errorf("%w: %s - %s", ErrAlbumNotFound, discography.Artist.Name, release.NewAlbumTitle) //it was probably a single or an EP
+	}
+
+	discography.NewestRelease = *newestRelease
+	discography.Watchlisted = true
+	discography.MatchedRules = []string{"always_include"}
+	return *discography, nil
+}
+
+// processWithRule uses the configured artist rule instead of the sub-genres and thresholds
+func (f Filterer) processWithRule(discography *allmusic.Discography, release allmusic.NewRelease) (interface{}, error) {
+	newestRelease := f.findNewRelease(discography, release.NewAlbumTitle)
+	if newestRelease == nil {
+		return nil, fmt.Errorf("%w: %s - %s", ErrAlbumNotFound, discography.Artist.Name, release.NewAlbumTitle) //it was probably a single or an EP
+	}
+	discography.NewestRelease = *newestRelease
+
+	env := discography.RuleEnv()
+	env.Strings["main_genre"] = release.Genres
+	if !f.conf.Rules.ArtistRule().Match(env) {
+		return nil, fmt.Errorf("%w: %s", ErrRuleNotMatched, discography.Artist.Name)
+	}
+
+	discography.MatchedRules = append(f.matchedNewReleaseRules(release), "artist: "+f.conf.Rules.ArtistRule().String())
 	return *discography, nil
 }
 
+// matchedNewReleaseRules returns why the release passed the filter of the new releases page
+func (f Filterer) matchedNewReleaseRules(release allmusic.NewRelease) []string {
+	if r := f.conf.Rules.NewReleaseRule(); r != nil {
+		return []string{"new_release: " + r.String()}
+	}
+
+	matched := make([]string, 0)
+	for _, g := range release.Genres {
+		if f.conf.IsInterestingMainGenre(g) {
+			matched = append(matched, "main_genre: "+g)
+		}
+	}
+	return matched
+}
+
 func (f Filterer) findNewRelease(discography *allmusic.Discography, releaseTitle string) *allmusic.Album {
 	for _, album := range discography.Albums {
 		if album.Title == releaseTitle {
@@ -103,11 +186,43 @@ func (f Filterer) findNewRelease(discography *allmusic.Discography, releaseTitle
 	return nil
 }
 
-func (f Filterer) artistHasInterestingGenre(genres []string) bool {
+// validateRatings checks the discography against the configured rating thresholds
+func (f Filterer) validateRatings(discography *allmusic.Discography, ratedAlbums int) error {
+	thresholds := f.conf.Thresholds
+
+	switch {
+	case discography.BestRating < thresholds.MinBestRating:
+		return fmt.Errorf("%w: %s (best rating is %d)", ErrNotHighEnoughRatings, discography.Artist.Name, discography.BestRating)
+	case discography.AverageRating < thresholds.MinAverageRating:
+		return fmt.Errorf("%w: %s (average rating is %d)", ErrNotHighEnoughRatings, discography.Artist.Name, discography.AverageRating)
+	case ratedAlbums < thresholds.MinRatedAlbums:
+		return fmt.Errorf("%w: %s (only %d rated albums)", ErrNotHighEnoughRatings, discography.Artist.Name, ratedAlbums)
+	}
+	return nil
+}
+
+// findExcludedGenre returns the first of the artist's genres which is excluded or an empty string if there is none
+func (f Filterer) findExcludedGenre(genres []string) string {
+	for _, g := range genres {
+		if f.conf.IsExcludedSubGenre(g) {
+			return g
+		}
+	}
+	return ""
+}
+
+// countInterestingGenres returns how many of the artist's genres match the configured sub-genres
+func (f Filterer) countInterestingGenres(genres []string) int {
+	return len(f.matchedGenres(genres))
+}
+
+// matchedGenres returns the artist's genres which match the configured sub-genres
+func (f Filterer) matchedGenres(genres []string) []string {
+	matched := make([]string, 0)
 	for _, g := range genres {
 		if f.conf.IsInterestingSubGenre(g) {
-			return true
+			matched = append(matched, "sub_genre: "+g)
 		}
 	}
-	return false
+	return matched
 }
@@ -0,0 +1,331 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+)
+
+type fakeDiscographyProvider map[string]allmusic.Discography
+
+func (p fakeDiscographyProvider) GetArtistDiscography(ctx context.Context, link string) (*allmusic.Discography, error) {
+	discography, ok := p[link]
+	if !ok {
+		return nil, fmt.Errorf("status code error: 404 Not Found")
+	}
+	return &discography, nil
+}
+
+var testDiscographies = fakeDiscographyProvider{
+	"king-diamond": {
+		Artist: allmusic.Artist{Name: "King Diamond", Genres: []string{"Heavy Metal", "Black Metal"}},
+		Albums: []allmusic.Album{
+			{Title: "Abigail", Rating: 9},
+			{Title: "The Institute", Rating: 0},
+		},
+		BestRating: 9,
+	},
+	"nickelback": {
+		Artist:     allmusic.Artist{Name: "Nickelback", Genres: []string{"Post-Grunge"}},
+		Albums:     []allmusic.Album{{Title: "Silver Side Up", Rating: 6}},
+		BestRating: 6,
+	},
+	"manowar": {
+		Artist:     allmusic.Artist{Name: "Manowar", Genres: []string{"Heavy Metal"}},
+		Albums:     []allmusic.Album{{Title: "Kings of Metal", Rating: 7}},
+		BestRating: 7,
+	},
+	"ghost": {
+		Artist: allmusic.Artist{Name: "Ghost", Genres: []string{"Heavy Metal", "Doom Metal"}},
+		Albums: []allmusic.Album{{Title: "Opus Eponymous"}},
+	},
+	"spiritbox": {
+		Artist: allmusic.Artist{Name: "Spiritbox", Genres: []string{"Alternative Metal", "Post-Hardcore"}},
+		Albums: []allmusic.Album{{Title: "Eternal Blue"}},
+	},
+	"mercyful-fate": {
+		Artist:        allmusic.Artist{Name: "Mercyful Fate", Genres: []string{"Heavy Metal"}},
+		Albums:        []allmusic.Album{{Title: "Melissa", Rating: 9}, {Title: "Don't Break the Oath", Rating: 9}, {Title: "In the Shadows", Rating: 6}},
+		BestRating:    9,
+		AverageRating: 8,
+	},
+}
+
+func testConfig() config.Config {
+	conf := config.Config{}
+	conf.SubGenres.FuzzyMatches = []string{"Metal"}
+	conf.SubGenres.ExactMatches = []string{"Grunge"}
+	conf.SubGenres.ExcludeExact = []string{"Post-Hardcore"}
+	conf.Thresholds = config.Thresholds{MinBestRating: 8}
+	return conf
+}
+
+func Test_processNewRelease(t *testing.T) {
+	testcases := map[string]struct {
+		Release       allmusic.NewRelease
+		Thresholds    *config.Thresholds
+		ExpectedErr   error
+		ExpectedTitle string
+	}{
+		"Interesting release": {
+			Release:       allmusic.NewRelease{ArtistLink: "king-diamond", NewAlbumTitle: "The Institute"},
+			ExpectedTitle: "The Institute",
+		},
+		"Single or EP": {
+			Release:     allmusic.NewRelease{ArtistLink: "king-diamond", NewAlbumTitle: "Masquerade of Madness"},
+			ExpectedErr: ErrAlbumNotFound,
+		},
+		"Uninteresting genre": {
+			Release:     allmusic.NewRelease{ArtistLink: "nickelback", NewAlbumTitle: "Silver Side Up"},
+			ExpectedErr: ErrNotInterestingGenre,
+		},
+		"Ratings too low": {
+			Release:     allmusic.NewRelease{ArtistLink: "manowar", NewAlbumTitle: "Kings of Metal"},
+			ExpectedErr: ErrNotHighEnoughRatings,
+		},
+		"Lower best rating threshold": {
+			Release:       allmusic.NewRelease{ArtistLink: "manowar", NewAlbumTitle: "Kings of Metal"},
+			Thresholds:    &config.Thresholds{MinBestRating: 7},
+			ExpectedTitle: "Kings of Metal",
+		},
+		"Average rating too low": {
+			Release:     allmusic.NewRelease{ArtistLink: "mercyful-fate", NewAlbumTitle: "In the Shadows"},
+			Thresholds:  &config.Thresholds{MinBestRating: 8, MinAverageRating: 9},
+			ExpectedErr: ErrNotHighEnoughRatings,
+		},
+		"Too few rated albums": {
+			Release:     allmusic.NewRelease{ArtistLink: "mercyful-fate", NewAlbumTitle: "In the Shadows"},
+			Thresholds:  &config.Thresholds{MinBestRating: 8, MinRatedAlbums: 4},
+			ExpectedErr: ErrNotHighEnoughRatings,
+		},
+		"Newest release rated too low": {
+			Release:     allmusic.NewRelease{ArtistLink: "mercyful-fate", NewAlbumTitle: "In the Shadows"},
+			Thresholds:  &config.Thresholds{MinBestRating: 8, MinNewestRating: 8},
+			ExpectedErr: ErrNotHighEnoughRatings,
+		},
+		"Newest release rated well": {
+			Release:       allmusic.NewRelease{ArtistLink: "mercyful-fate", NewAlbumTitle: "Melissa"},
+			Thresholds:    &config.Thresholds{MinBestRating: 8, MinAverageRating: 8, MinRatedAlbums: 3, MinNewestRating: 8},
+			ExpectedTitle: "Melissa",
+		},
+		"Debuts not allowed": {
+			Release:     allmusic.NewRelease{ArtistLink: "ghost", NewAlbumTitle: "Opus Eponymous"},
+			ExpectedErr: ErrNotHighEnoughRatings,
+		},
+		"Debut with strong genre match": {
+			Release:       allmusic.NewRelease{ArtistLink: "ghost", NewAlbumTitle: "Opus Eponymous"},
+			Thresholds:    &config.Thresholds{MinBestRating: 8, Debuts: config.Debuts{Allowed: true, MinGenreMatches: 2}},
+			ExpectedTitle: "Opus Eponymous",
+		},
+		"Debut with weak genre match": {
+			Release:     allmusic.NewRelease{ArtistLink: "ghost", NewAlbumTitle: "Opus Eponymous"},
+			Thresholds:  &config.Thresholds{MinBestRating: 8, Debuts: config.Debuts{Allowed: true, MinGenreMatches: 3}},
+			ExpectedErr: ErrNotHighEnoughRatings,
+		},
+		"Excluded genre": {
+			Release:     allmusic.NewRelease{ArtistLink: "spiritbox", NewAlbumTitle: "Eternal Blue"},
+			Thresholds:  &config.Thresholds{MinBestRating: 8, Debuts: config.Debuts{Allowed: true, MinGenreMatches: 1}},
+			ExpectedErr: ErrExcludedGenre,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		conf := testConfig()
+		if testdata.Thresholds != nil {
+			conf.Thresholds = *testdata.Thresholds
+		}
+		filterer := NewFilterer(conf, testDiscographies, nil)
+
+		result, err := filterer.processNewRelease(context.Background(), testdata.Release)
+
+		if testdata.ExpectedErr != nil {
+			assert.True(t, errors.Is(err, testdata.ExpectedErr), "%s: unexpected error %v", testcase, err)
+			continue
+		}
+		require.NoError(t, err, testcase)
+		assert.Equal(t, testdata.ExpectedTitle, result.(allmusic.Discography).NewestRelease.Title, testcase)
+	}
+}
+
+func Test_processNewRelease_Rule(t *testing.T) {
+	//given
+	conf := config.Config{}
+	err := conf.Parse([]byte(`lists:
+  watchlist: ["Nickelback"]
+rules:
+  artist: 'genre ~ "Metal" and not genre == "Doom Metal" and best_rating >= 7 or artist in watchlist'`))
+	require.NoError(t, err, "There was an error parsing the config")
+
+	testcases := map[string]struct {
+		Release  allmusic.NewRelease
+		Expected bool
+	}{
+		"Matches genre and rating": {
+			Release:  allmusic.NewRelease{ArtistLink: "manowar", NewAlbumTitle: "Kings of Metal"},
+			Expected: true,
+		},
+		"Excluded genre": {
+			Release:  allmusic.NewRelease{ArtistLink: "ghost", NewAlbumTitle: "Opus Eponymous"},
+			Expected: false,
+		},
+		"Watchlist": {
+			Release:  allmusic.NewRelease{ArtistLink: "nickelback", NewAlbumTitle: "Silver Side Up"},
+			Expected: true,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		filterer := NewFilterer(conf, testDiscographies, nil)
+
+		_, err := filterer.processNewRelease(context.Background(), testdata.Release)
+
+		if testdata.Expected {
+			assert.NoError(t, err, testcase)
+		} else {
+			assert.True(t, errors.Is(err, ErrRuleNotMatched), "%s: unexpected error %v", testcase, err)
+		}
+	}
+}
+
+func Test_processNewRelease_MatchedRules(t *testing.T) {
+	ruleConf := config.Config{}
+	require.NoError(t, ruleConf.Parse([]byte(`rules:
+  new_release: 'main_genre ~ "Pop/Rock"'
+  artist: 'genre ~ "Metal"'`)))
+
+	watchlistConf := testConfig()
+	watchlistConf.AlwaysInclude = []string{"nickelback"}
+
+	genreConf := testConfig()
+	genreConf.MainGenres = []string{"Rock"}
+	genreConf.Thresholds.Debuts = config.Debuts{Allowed: true, MinGenreMatches: 2}
+
+	testcases := map[string]struct {
+		Conf     config.Config
+		Release  allmusic.NewRelease
+		Expected []string
+	}{
+		"Genres": {
+			Conf:     genreConf,
+			Release:  allmusic.NewRelease{ArtistLink: "king-diamond", NewAlbumTitle: "Abigail", Genres: []string{"Pop/Rock", "Jazz"}},
+			Expected: []string{"main_genre: Pop/Rock", "sub_genre: Heavy Metal", "sub_genre: Black Metal"},
+		},
+		"Debut": {
+			Conf:     genreConf,
+			Release:  allmusic.NewRelease{ArtistLink: "ghost", NewAlbumTitle: "Opus Eponymous", Genres: []string{"Pop/Rock"}},
+			Expected: []string{"main_genre: Pop/Rock", "sub_genre: Heavy Metal", "sub_genre: Doom Metal", "debut"},
+		},
+		"Rules": {
+			Conf:     ruleConf,
+			Release:  allmusic.NewRelease{ArtistLink: "manowar", NewAlbumTitle: "Kings of Metal", Genres: []string{"Pop/Rock"}},
+			Expected: []string{`new_release: main_genre ~ "Pop/Rock"`, `artist: genre ~ "Metal"`},
+		},
+		"Watchlist": {
+			Conf:     watchlistConf,
+			Release:  allmusic.NewRelease{ArtistLink: "nickelback", NewAlbumTitle: "Silver Side Up"},
+			Expected: []string{"always_include"},
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		//when
+		result, err := NewFilterer(testdata.Conf, testDiscographies, nil).processNewRelease(context.Background(), testdata.Release)
+
+		//then
+		require.NoError(t, err, testcase)
+		assert.Equal(t, testdata.Expected, result.(allmusic.Discography).MatchedRules, testcase)
+	}
+}
+
+func Test_processNewRelease_AllowAndBlockLists(t *testing.T) {
+	conf := testConfig()
+	conf.AlwaysInclude = []string{"nickelback", "ghost"}
+	conf.NeverInclude = []string{"King Diamond"}
+	filterer := NewFilterer(conf, testDiscographies, nil)
+
+	//always included despite the genre and ratings
+	result, err := filterer.processNewRelease(context.Background(), allmusic.NewRelease{ArtistLink: "nickelback", NewAlbumTitle: "Silver Side Up"})
+	require.NoError(t, err)
+	assert.True(t, result.(allmusic.Discography).Watchlisted)
+
+	//singles and EPs are still filtered
+	_, err = filterer.processNewRelease(context.Background(), allmusic.NewRelease{ArtistLink: "ghost", NewAlbumTitle: "Mary on a Cross"})
+	assert.True(t, errors.Is(err, ErrAlbumNotFound), "unexpected error %v", err)
+
+	//never included despite the genre and ratings
+	_, err = filterer.processNewRelease(context.Background(), allmusic.NewRelease{ArtistLink: "king-diamond", NewAlbumTitle: "The Institute"})
+	assert.True(t, errors.Is(err, ErrArtistBlocked), "unexpected error %v", err)
+
+	//other artists aren't flagged
+	result, err = filterer.processNewRelease(context.Background(), allmusic.NewRelease{ArtistLink: "mercyful-fate", NewAlbumTitle: "Melissa"})
+	require.NoError(t, err)
+	assert.False(t, result.(allmusic.Discography).Watchlisted)
+}
+
+func Test_processNewRelease_LookupError(t *testing.T) {
+	filterer := NewFilterer(testConfig(), testDiscographies, nil)
+
+	_, err := filterer.processNewRelease(context.Background(), allmusic.NewRelease{ArtistLink: "unknown", NewAlbumTitle: "Unknown"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown")
+}
+
+// slowDiscographyProvider blocks on the given artists until the request is canceled
+type slowDiscographyProvider struct {
+	fakeDiscographyProvider
+	hungArtists map[string]bool
+}
+
+func (p slowDiscographyProvider) GetArtistDiscography(ctx context.Context, link string) (*allmusic.Discography, error) {
+	if p.hungArtists[link] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return p.fakeDiscographyProvider.GetArtistDiscography(ctx, link)
+}
+
+func Test_FilterAndEnrich_Canceled(t *testing.T) {
+	//given
+	releases := []allmusic.NewRelease{
+		{ArtistLink: "king-diamond", NewAlbumTitle: "The Institute"},
+		{ArtistLink: "hung", NewAlbumTitle: "Forever"},
+	}
+	provider := slowDiscographyProvider{fakeDiscographyProvider: testDiscographies, hungArtists: map[string]bool{"hung": true}}
+	filterer := NewFilterer(testConfig(), provider, releases)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	//when
+	discographies := filterer.FilterAndEnrich(ctx)
+
+	//then
+	require.Equal(t, 1, len(discographies), "The results found before the cancellation should be returned")
+	assert.Equal(t, "King Diamond", discographies[0].Artist.Name)
+}
+
+func Test_FilterAndEnrich(t *testing.T) {
+	//given
+	releases := []allmusic.NewRelease{
+		{ArtistLink: "king-diamond", NewAlbumTitle: "The Institute"},
+		{ArtistLink: "nickelback", NewAlbumTitle: "Silver Side Up"},
+		{ArtistLink: "manowar", NewAlbumTitle: "Kings of Metal"},
+		{ArtistLink: "unknown", NewAlbumTitle: "Unknown"},
+	}
+	filterer := NewFilterer(testConfig(), testDiscographies, releases)
+
+	//when
+	discographies := filterer.FilterAndEnrich(context.Background())
+
+	//then
+	require.Equal(t, 1, len(discographies))
+	assert.Equal(t, "King Diamond", discographies[0].Artist.Name)
+}
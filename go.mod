@@ -9,6 +9,9 @@ require (
 	github.com/stretchr/testify v1.8.4
 	github.com/ynori7/hulksmash v1.1.5
 	github.com/ynori7/workerpool v1.2.3
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/image v0.25.0
+	golang.org/x/time v0.9.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
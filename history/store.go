@@ -0,0 +1,182 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ynori7/music/allmusic"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	releasesBucket      = []byte("releases")      //every new release which was seen, keyed by week and album
+	discographiesBucket = []byte("discographies") //the latest discography fetched for each artist, keyed by artist link
+	reportedBucket      = []byte("reported")      //a nested bucket per profile with every album which was reported to it
+)
+
+// Store is a local database which remembers what previous runs have seen and reported
+type Store struct {
+	db *bolt.DB
+}
+
+type seenRelease struct {
+	Week    string
+	SeenAt  time.Time
+	Release allmusic.NewRelease
+}
+
+type fetchedDiscography struct {
+	FetchedAt   time.Time
+	Discography allmusic.Discography
+}
+
+// ReportedAlbum is an album which was included in a report for a profile
+type ReportedAlbum struct {
+	Week        string
+	ReportedAt  time.Time
+	Discography allmusic.Discography
+}
+
+// Open opens the database at the given path, creating it if necessary
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second}) //don't wait forever if another run holds the lock
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{releasesBucket, discographiesBucket, reportedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// RecordNewReleases saves the releases which were found for the week
+func (s *Store) RecordNewReleases(week string, releases []allmusic.NewRelease) error {
+	now := time.Now()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(releasesBucket)
+		for _, release := range releases {
+			data, err := json.Marshal(seenRelease{Week: week, SeenAt: now, Release: release})
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(week+"|"+release.ArtistLink+"|"+release.NewAlbumTitle), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RecordDiscography saves the discography, replacing the previous one of the artist
+func (s *Store) RecordDiscography(discography allmusic.Discography) error {
+	data, err := json.Marshal(fetchedDiscography{FetchedAt: time.Now(), Discography: discography})
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(discographiesBucket).Put([]byte(discography.Artist.Link), data)
+	})
+}
+
+// RecordReported saves the new releases of the discographies as reported to the profile
+func (s *Store) RecordReported(profile, week string, discographies []allmusic.Discography) error {
+	now := time.Now()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(reportedBucket).CreateBucketIfNotExists([]byte(profile))
+		if err != nil {
+			return err
+		}
+		for _, discography := range discographies {
+			data, err := json.Marshal(ReportedAlbum{Week: week, ReportedAt: now, Discography: discography})
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(AlbumKey(discography)), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WasReported checks if the new release of the discography was already reported to the profile
+func (s *Store) WasReported(profile string, discography allmusic.Discography) (bool, error) {
+	reported := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reportedBucket).Bucket([]byte(profile))
+		reported = bucket != nil && bucket.Get([]byte(AlbumKey(discography))) != nil
+		return nil
+	})
+	return reported, err
+}
+
+// ReportedSince returns the albums which were reported to the profile for the given week or any later one
+func (s *Store) ReportedSince(profile, week string) ([]ReportedAlbum, error) {
+	albums := make([]ReportedAlbum, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reportedBucket).Bucket([]byte(profile))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var album ReportedAlbum
+			if err := json.Unmarshal(v, &album); err != nil {
+				return err
+			}
+			if album.Week >= week { //the weeks are formatted as yyyyMMdd, so they can be compared as strings
+				albums = append(albums, album)
+			}
+			return nil
+		})
+	})
+	return albums, err
+}
+
+// AlbumKey identifies the new release of the discography. The album link is used when available.
+func AlbumKey(discography allmusic.Discography) string {
+	if discography.NewestRelease.Link != "" {
+		return discography.NewestRelease.Link
+	}
+	return discography.Artist.Link + "|" + discography.NewestRelease.Title
+}
+
+// RecordingDiscographyProvider saves every discography which is fetched by the wrapped provider
+type RecordingDiscographyProvider struct {
+	provider allmusic.DiscographyProvider
+	store    *Store
+}
+
+func NewRecordingDiscographyProvider(provider allmusic.DiscographyProvider, store *Store) RecordingDiscographyProvider {
+	return RecordingDiscographyProvider{
+		provider: provider,
+		store:    store,
+	}
+}
+
+func (p RecordingDiscographyProvider) GetArtistDiscography(ctx context.Context, link string) (*allmusic.Discography, error) {
+	discography, err := p.provider.GetArtistDiscography(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.store.RecordDiscography(*discography); err != nil {
+		log.WithFields(log.Fields{"Logger": "RecordingDiscographyProvider", "error": err}).Warn("Error saving discography to history")
+	}
+	return discography, nil
+}
@@ -0,0 +1,142 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	bolt "go.etcd.io/bbolt"
+)
+
+var kingDiamond = allmusic.Discography{
+	Artist:        allmusic.Artist{Name: "King Diamond", Link: "https://www.allmusic.com/artist/king-diamond-mn0000770007"},
+	NewestRelease: allmusic.Album{Title: "The Institute", Link: "https://www.allmusic.com/album/the-institute-mw0000000001"},
+}
+
+func openTestStore(t *testing.T) *Store {
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err, "There was an error opening the store")
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func Test_RecordReported(t *testing.T) {
+	//given
+	store := openTestStore(t)
+
+	//when
+	reportedBefore, err := store.WasReported("metal", kingDiamond)
+	require.NoError(t, err)
+	require.NoError(t, store.RecordReported("metal", "20200327", []allmusic.Discography{kingDiamond}))
+
+	//then
+	assert.False(t, reportedBefore)
+
+	reported, err := store.WasReported("metal", kingDiamond)
+	require.NoError(t, err)
+	assert.True(t, reported, "The album should be reported to the profile")
+
+	reported, err = store.WasReported("jazz", kingDiamond)
+	require.NoError(t, err)
+	assert.False(t, reported, "The album was not reported to a different profile")
+
+	nextAlbum := kingDiamond
+	nextAlbum.NewestRelease = allmusic.Album{Title: "Saint Lucifer's Hospital 1920", Link: "https://www.allmusic.com/album/saint-lucifers-hospital-1920-mw0000000002"}
+	reported, err = store.WasReported("metal", nextAlbum)
+	require.NoError(t, err)
+	assert.False(t, reported, "A different album of the same artist was not reported")
+}
+
+func Test_ReportedSince(t *testing.T) {
+	//given
+	store := openTestStore(t)
+
+	nextAlbum := kingDiamond
+	nextAlbum.NewestRelease = allmusic.Album{Title: "Saint Lucifer's Hospital 1920", Link: "https://www.allmusic.com/album/saint-lucifers-hospital-1920-mw0000000002"}
+	require.NoError(t, store.RecordReported("metal", "20200320", []allmusic.Discography{kingDiamond}))
+	require.NoError(t, store.RecordReported("metal", "20200327", []allmusic.Discography{nextAlbum}))
+	require.NoError(t, store.RecordReported("jazz", "20200327", []allmusic.Discography{kingDiamond}))
+
+	//when
+	albums, err := store.ReportedSince("metal", "20200327")
+
+	//then
+	require.NoError(t, err)
+	require.Len(t, albums, 1)
+	assert.Equal(t, "20200327", albums[0].Week)
+	assert.Equal(t, nextAlbum.NewestRelease.Title, albums[0].Discography.NewestRelease.Title)
+
+	albums, err = store.ReportedSince("electronic", "20200327")
+	require.NoError(t, err)
+	assert.Empty(t, albums, "Nothing was reported to the profile")
+}
+
+func Test_Persistence(t *testing.T) {
+	//given
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := Open(path)
+	require.NoError(t, err)
+
+	//when
+	require.NoError(t, store.RecordNewReleases("20200327", []allmusic.NewRelease{{ArtistLink: kingDiamond.Artist.Link, NewAlbumTitle: "The Institute"}}))
+	require.NoError(t, store.RecordDiscography(kingDiamond))
+	require.NoError(t, store.RecordReported("metal", "20200327", []allmusic.Discography{kingDiamond}))
+	require.NoError(t, store.Close())
+
+	//then
+	store, err = Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	reported, err := store.WasReported("metal", kingDiamond)
+	require.NoError(t, err)
+	assert.True(t, reported, "The reported albums should survive reopening the store")
+
+	store.db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, 1, tx.Bucket(releasesBucket).Stats().KeyN)
+		assert.NotNil(t, tx.Bucket(discographiesBucket).Get([]byte(kingDiamond.Artist.Link)))
+		return nil
+	})
+}
+
+func Test_AlbumKey(t *testing.T) {
+	assert.Equal(t, "https://www.allmusic.com/album/the-institute-mw0000000001", AlbumKey(kingDiamond))
+
+	withoutLink := kingDiamond
+	withoutLink.NewestRelease.Link = ""
+	assert.Equal(t, "https://www.allmusic.com/artist/king-diamond-mn0000770007|The Institute", AlbumKey(withoutLink))
+}
+
+type fakeDiscographyProvider struct{}
+
+func (p fakeDiscographyProvider) GetArtistDiscography(ctx context.Context, link string) (*allmusic.Discography, error) {
+	if link != kingDiamond.Artist.Link {
+		return nil, fmt.Errorf("status code error: 404 Not Found")
+	}
+	discography := kingDiamond
+	return &discography, nil
+}
+
+func Test_RecordingDiscographyProvider(t *testing.T) {
+	//given
+	store := openTestStore(t)
+	provider := NewRecordingDiscographyProvider(fakeDiscographyProvider{}, store)
+
+	//when
+	discography, err := provider.GetArtistDiscography(context.Background(), kingDiamond.Artist.Link)
+	_, missingErr := provider.GetArtistDiscography(context.Background(), "https://www.allmusic.com/artist/unknown")
+
+	//then
+	require.NoError(t, err)
+	assert.Equal(t, "King Diamond", discography.Artist.Name)
+	assert.Error(t, missingErr)
+
+	store.db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, 1, tx.Bucket(discographiesBucket).Stats().KeyN, "Only the fetched discography should be saved")
+		return nil
+	})
+}
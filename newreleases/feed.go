@@ -0,0 +1,73 @@
+package newreleases
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/view"
+)
+
+// updateFeed rebuilds the feeds of the profile from the albums which were reported in the last weeks up to the given
+// week. Errors are only logged because the report itself was already generated.
+func (h newReleasesHandler) updateFeed(profile config.Config, week allmusic.ReleaseWeek) {
+	logger := log.WithFields(log.Fields{"Logger": "updateFeed", "Profile": profile.Title})
+
+	if !profile.Feed.Enabled || h.history == nil {
+		return
+	}
+
+	since := week.AddWeeks(1 - profile.Feed.Weeks)
+	reported, err := h.history.ReportedSince(profile.Title, since.String())
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Warn("Error reading reported albums from history")
+		return
+	}
+
+	entries := make([]view.FeedEntry, 0, len(reported))
+	for _, album := range reported {
+		reportedWeek, err := allmusic.ParseReleaseWeek(album.Week)
+		if err != nil || week.Before(reportedWeek) {
+			continue //the feed ends with the given week, e.g. when an older week is generated again
+		}
+		entries = append(entries, view.FeedEntry{Week: reportedWeek, Published: album.ReportedAt, Discography: album.Discography})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if weekI, weekJ := entries[i].Week.String(), entries[j].Week.String(); weekI != weekJ {
+			return weekI > weekJ
+		}
+		return entries[i].Discography.Score > entries[j].Discography.Score
+	})
+
+	for _, format := range []string{"atom", "rss"} {
+		file := fmt.Sprintf("%s.%s.xml", profile.Title, format)
+		feed := view.NewFeed(profile.Title, feedUrl(profile.Feed.Url, file), entries)
+
+		var out []byte
+		if format == "atom" {
+			out, err = feed.ExecuteAtom()
+		} else {
+			out, err = feed.ExecuteRss()
+		}
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err, "Format": format}).Warn("Error generating feed")
+			continue
+		}
+
+		if err := os.WriteFile(fmt.Sprintf("%s/%s", config.CliConf.OutputPath, file), out, 0644); err != nil {
+			logger.WithFields(log.Fields{"error": err, "Format": format}).Warn("Error saving feed to file")
+		}
+	}
+}
+
+// feedUrl returns where the feed file is published, if the output directory is published at all
+func feedUrl(baseUrl, file string) string {
+	if baseUrl == "" {
+		return ""
+	}
+	return strings.TrimSuffix(baseUrl, "/") + "/" + file
+}
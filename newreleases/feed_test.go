@@ -0,0 +1,54 @@
+package newreleases
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/history"
+)
+
+func Test_updateFeed(t *testing.T) {
+	//given
+	config.CliConf = config.CliConfig{OutputPath: t.TempDir()}
+
+	conf := config.Config{Profile: config.Profile{Title: "metal"}}
+	conf.Feed = config.Feed{Enabled: true, Weeks: 2, Url: "https://music.mysite.com/"}
+
+	historyStore, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err, "There was an error opening the history")
+	defer historyStore.Close()
+
+	albums := map[string]allmusic.Discography{
+		"20200306": {Artist: allmusic.Artist{Name: "Mercyful Fate"}, NewestRelease: allmusic.Album{Title: "Melissa", Link: "https://www.allmusic.com/album/melissa"}},
+		"20200320": {Artist: allmusic.Artist{Name: "Ghost"}, NewestRelease: allmusic.Album{Title: "Impera", Link: "https://www.allmusic.com/album/impera"}},
+		"20200327": {Artist: allmusic.Artist{Name: "King Diamond"}, NewestRelease: allmusic.Album{Title: "The Institute", Link: "https://www.allmusic.com/album/the-institute"}},
+		"20200403": {Artist: allmusic.Artist{Name: "Manowar"}, NewestRelease: allmusic.Album{Title: "Kings of Metal", Link: "https://www.allmusic.com/album/kings-of-metal"}},
+	}
+	for week, album := range albums {
+		require.NoError(t, historyStore.RecordReported("metal", week, []allmusic.Discography{album}))
+	}
+
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err, "There was an error parsing the release week")
+
+	//when
+	NewReleasesHandler(conf, nil, nil, historyStore).updateFeed(conf, week)
+
+	//then
+	atom, err := os.ReadFile(filepath.Join(config.CliConf.OutputPath, "metal.atom.xml"))
+	require.NoError(t, err, "The atom feed should have been saved")
+	assert.Contains(t, string(atom), `<link href="https://music.mysite.com/metal.atom.xml" rel="self"></link>`)
+	assert.NotContains(t, string(atom), "Melissa", "The week is too old for the feed")
+	assert.NotContains(t, string(atom), "Kings of Metal", "The week is after the generated week")
+	assert.Less(t, strings.Index(string(atom), "The Institute"), strings.Index(string(atom), "Impera"), "Newer weeks should be listed first")
+
+	rss, err := os.ReadFile(filepath.Join(config.CliConf.OutputPath, "metal.rss.xml"))
+	require.NoError(t, err, "The rss feed should have been saved")
+	assert.Contains(t, string(rss), `<guid isPermaLink="true">https://www.allmusic.com/album/impera</guid>`)
+}
@@ -1,61 +1,332 @@
 package newreleases
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/ynori7/music/allmusic"
 	"github.com/ynori7/music/config"
 	"github.com/ynori7/music/filter"
+	"github.com/ynori7/music/history"
 	"github.com/ynori7/music/view"
 )
 
 type newReleasesHandler struct {
-	config config.Config
+	profiles            []config.Config
+	releaseSource       allmusic.NewReleaseSource
+	discographyProvider allmusic.DiscographyProvider
+	history             *history.Store //optional
+	templates           view.Templates
+}
+
+// Report is a generated report of a profile and the release weeks it covers
+type Report struct {
+	Config        config.Config //the config of the profile
+	From          allmusic.ReleaseWeek
+	To            allmusic.ReleaseWeek
+	Discographies []allmusic.Discography
+	Html          string //empty when the reports aren't saved as html
+	HtmlFile      string //the name of the saved html report in the output path
+
+	weeks map[allmusic.ReleaseWeek][]allmusic.Discography //the discographies of each week, recorded once delivered
 }
 
 func NewReleasesHandler(
 	conf config.Config,
+	releaseSource allmusic.NewReleaseSource,
+	discographyProvider allmusic.DiscographyProvider,
+	historyStore *history.Store,
 ) newReleasesHandler {
 	return newReleasesHandler{
-		config: conf,
+		profiles:            conf.ProfileConfigs(),
+		releaseSource:       releaseSource,
+		discographyProvider: discographyProvider,
+		history:             historyStore,
 	}
 }
 
-func (h newReleasesHandler) GenerateNewReleasesReport(week string) (string, error) {
-	logger := log.WithFields(log.Fields{"Logger": "GenerateNewReleasesReport"})
+// WithTemplates renders the reports with custom templates instead of the built-in ones
+func (h newReleasesHandler) WithTemplates(templates view.Templates) newReleasesHandler {
+	h.templates = templates
+	return h
+}
+
+// GenerateNewReleasesReports builds a report of the given week for each profile and saves them to the output path.
+// The new releases are only fetched once for all profiles. If the context is canceled while the discographies are
+// being looked up, partial reports are still generated. The albums aren't remembered as reported until
+// RecordDelivered is called.
+func (h newReleasesHandler) GenerateNewReleasesReports(ctx context.Context, week allmusic.ReleaseWeek) ([]Report, error) {
+	logger := log.WithFields(log.Fields{"Logger": "GenerateNewReleasesReports"})
+
+	if week.IsZero() {
+		week = allmusic.ReleaseWeekOf(time.Now())
+	}
 
-	//Fetch the new releases (filtered by top-level genre)
-	newReleases, err := allmusic.NewReleasesClient(h.config).GetPotentiallyInterestingNewReleases(allmusic.GetNewReleasesUrlForWeek(week))
+	profileDiscographies, err := h.findInterestingDiscographies(ctx, week)
 	if err != nil {
-		logger.WithFields(log.Fields{"error": err}).Error("Error fetching new releases")
-		return "", err
+		return nil, err
 	}
 
-	//Fetch the discographies and filter the releases
-	filterer := filter.NewFilterer(h.config, allmusic.NewDiscographyClient(), newReleases)
-	interestingDiscographies := filterer.FilterAndEnrich()
+	reports := make([]Report, 0, len(h.profiles))
+	errs := make([]error, 0)
+	for i, profile := range h.profiles {
+		report, err := h.saveReport(Report{
+			Config:        profile,
+			From:          week,
+			To:            week,
+			Discographies: profileDiscographies[i],
+			weeks:         map[allmusic.ReleaseWeek][]allmusic.Discography{week: profileDiscographies[i]},
+		}, week.String())
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err, "Profile": profile.Title}).Error("Error generating report for profile")
+			errs = append(errs, err)
+			continue
+		}
 
-	//Build HTML output
-	template := view.NewHtmlTemplate(interestingDiscographies)
-	out, err := template.ExecuteHtmlTemplate()
-	if err != nil {
-		logger.WithFields(log.Fields{"error": err}).Error("Error generating html")
-		return "", err
+		reports = append(reports, report)
 	}
 
-	//Save HTML output to file
-	dateString := week
-	if week == "" {
-		dateString = time.Now().Format("20060102") //yyyyMMdd
+	if len(reports) == 0 {
+		return nil, errors.Join(errs...)
 	}
-	err = os.WriteFile(fmt.Sprintf("%s/%s-%s.html", config.CliConf.OutputPath, h.config.Title, dateString), []byte(out), 0644)
+	return reports, nil
+}
+
+// GenerateBackfillReports runs the pipeline for each of the release weeks and builds either one report per week and
+// profile or, when combine is true, a single report per profile containing all of the weeks. Weeks which fail are
+// logged and skipped.
+func (h newReleasesHandler) GenerateBackfillReports(ctx context.Context, weeks []allmusic.ReleaseWeek, combine bool) ([]Report, error) {
+	logger := log.WithFields(log.Fields{"Logger": "GenerateBackfillReports"})
+
+	if len(weeks) == 0 {
+		return nil, fmt.Errorf("there are no release weeks to backfill")
+	}
+
+	reports := make([]Report, 0)
+	weeklyDiscographies := make(map[allmusic.ReleaseWeek][][]allmusic.Discography)
+	for _, week := range weeks {
+		if ctx.Err() != nil {
+			logger.WithFields(log.Fields{"error": ctx.Err()}).Warn("Backfill was interrupted, the remaining weeks are skipped")
+			break
+		}
+
+		if !combine {
+			weeklyReports, err := h.GenerateNewReleasesReports(ctx, week)
+			if err != nil {
+				logger.WithFields(log.Fields{"error": err, "Week": week}).Error("Error generating report for week")
+				continue
+			}
+			reports = append(reports, weeklyReports...)
+			continue
+		}
+
+		profileDiscographies, err := h.findInterestingDiscographies(ctx, week)
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err, "Week": week}).Error("Error generating report for week")
+			continue
+		}
+		weeklyDiscographies[week] = profileDiscographies
+	}
+
+	if combine && len(weeklyDiscographies) > 0 {
+		from, to := weeks[0], weeks[len(weeks)-1]
+		for i, profile := range h.profiles {
+			//The same album can be listed in several weeks, e.g. when the release date was moved
+			combined := make([]allmusic.Discography, 0)
+			seen := make(map[string]bool)
+			for _, week := range weeks {
+				if _, ok := weeklyDiscographies[week]; !ok {
+					continue
+				}
+				for _, discography := range weeklyDiscographies[week][i] {
+					key := history.AlbumKey(discography)
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+					combined = append(combined, discography)
+				}
+			}
+			sortByScore(combined)
+
+			profileWeeks := make(map[allmusic.ReleaseWeek][]allmusic.Discography, len(weeklyDiscographies))
+			for week, profileDiscographies := range weeklyDiscographies {
+				profileWeeks[week] = profileDiscographies[i]
+			}
+
+			report, err := h.saveReport(Report{Config: profile, From: from, To: to, Discographies: combined, weeks: profileWeeks}, from.String()+"-"+to.String())
+			if err != nil {
+				logger.WithFields(log.Fields{"error": err, "Profile": profile.Title}).Error("Error generating report for profile")
+				continue
+			}
+			reports = append(reports, report)
+		}
+	}
+
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("no report could be generated for any of the weeks")
+	}
+	return reports, nil
+}
+
+// findInterestingDiscographies fetches the new releases of the week once and filters them for each of the profiles.
+// The result contains the interesting discographies of each profile in the same order as the profiles.
+func (h newReleasesHandler) findInterestingDiscographies(ctx context.Context, week allmusic.ReleaseWeek) ([][]allmusic.Discography, error) {
+	logger := log.WithFields(log.Fields{"Logger": "findInterestingDiscographies", "Week": week.String()})
+
+	//Fetch the new releases
+	newReleases, err := h.releaseSource.GetNewReleasesForWeek(ctx, week)
 	if err != nil {
-		logger.WithFields(log.Fields{"error": err}).Warn("Error saving html to file")
-		return "", err
+		logger.WithFields(log.Fields{"error": err}).Error("Error fetching new releases")
+		return nil, err
+	}
+	if h.isRecording() {
+		if err := h.history.RecordNewReleases(week.String(), newReleases); err != nil {
+			logger.WithFields(log.Fields{"error": err}).Warn("Error saving new releases to history")
+		}
 	}
 
-	return out, nil
+	profileDiscographies := make([][]allmusic.Discography, 0, len(h.profiles))
+	for _, profile := range h.profiles {
+		//Filter the releases by top-level genre, then fetch the discographies and filter them by sub-genre and ratings
+		potentialReleases := allmusic.FilterPotentiallyInteresting(profile, newReleases)
+		filterer := filter.NewFilterer(profile, h.discographyProvider, potentialReleases)
+		interestingDiscographies := filterer.FilterAndEnrich(ctx)
+		if h.history != nil && !config.CliConf.Resend {
+			interestingDiscographies = h.skipReported(profile, interestingDiscographies)
+		}
+		profileDiscographies = append(profileDiscographies, interestingDiscographies)
+	}
+
+	return profileDiscographies, nil
+}
+
+// saveReport builds the report in each of the output formats and saves it to the output path
+func (h newReleasesHandler) saveReport(report Report, dateString string) (Report, error) {
+	logger := log.WithFields(log.Fields{"Logger": "saveReport"})
+
+	for _, format := range outputFormats(report.Config) {
+		var out []byte
+		var err error
+		extension := format
+		switch format {
+		case config.OutputHtml:
+			report.Html, err = view.NewHtmlTemplate(report.Discographies).WithTemplates(h.templates).ExecuteHtmlTemplate()
+			out = []byte(report.Html)
+		case config.OutputJson:
+			out, err = view.NewJsonReport(report.Config.Title, report.From, report.To, report.Discographies).ExecuteJson()
+		case config.OutputCsv:
+			out, err = view.NewCsvReport(report.Discographies).ExecuteCsv()
+		case config.OutputMarkdown:
+			template := view.NewMarkdownTemplate(report.Discographies)
+			if report.Config.MarkdownStyle == config.MarkdownList {
+				template = template.AsList()
+			}
+			var markdown string
+			markdown, err = template.ExecuteMarkdownTemplate()
+			out, extension = []byte(markdown), "md"
+		default:
+			err = fmt.Errorf("unknown output format: %s", format)
+		}
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err, "Format": format}).Error("Error generating report")
+			return report, err
+		}
+
+		//Save output to file
+		file := fmt.Sprintf("%s-%s.%s", report.Config.Title, dateString, extension)
+		err = os.WriteFile(fmt.Sprintf("%s/%s", config.CliConf.OutputPath, file), out, 0644)
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err, "Format": format}).Warn("Error saving report to file")
+			return report, err
+		}
+		if format == config.OutputHtml {
+			report.HtmlFile = file
+		}
+	}
+
+	return report, nil
+}
+
+// outputFormats returns the formats of the command line along with the additional ones of the profile
+func outputFormats(profile config.Config) []string {
+	formats := config.CliConf.Formats
+	if len(formats) == 0 {
+		formats = []string{config.OutputHtml}
+	}
+
+	result := make([]string, 0, len(formats)+len(profile.Formats))
+	seen := make(map[string]bool)
+	for _, format := range slices.Concat(formats, profile.Formats) {
+		if !seen[format] {
+			seen[format] = true
+			result = append(result, format)
+		}
+	}
+	return result
+}
+
+// RecordDelivered remembers the albums of the delivered reports so that following runs don't report them again and
+// updates the feeds. A report counts as delivered when at least one delivery of its profile succeeded or when it
+// wasn't sent anywhere. Nothing is remembered when the responses are replayed.
+func (h newReleasesHandler) RecordDelivered(reports []Report, summary RunSummary) {
+	logger := log.WithFields(log.Fields{"Logger": "RecordDelivered"})
+
+	for _, report := range reports {
+		if !summary.Delivered(report) {
+			logger.WithFields(log.Fields{"Profile": report.Config.Title}).Warn("The report wasn't delivered, its albums will be reported again")
+			continue
+		}
+		for week, discographies := range report.weeks {
+			h.recordReported(report.Config, week, discographies)
+		}
+		h.updateFeed(report.Config, report.To)
+	}
+}
+
+// recordReported remembers what was reported so that following runs don't report it again
+func (h newReleasesHandler) recordReported(profile config.Config, week allmusic.ReleaseWeek, discographies []allmusic.Discography) {
+	if !h.isRecording() {
+		return
+	}
+	if err := h.history.RecordReported(profile.Title, week.String(), discographies); err != nil {
+		log.WithFields(log.Fields{"Logger": "recordReported", "error": err}).Warn("Error saving reported albums to history")
+	}
+}
+
+// isRecording returns whether the history is updated. Replayed runs only read from it, so reproducing a run doesn't
+// change it.
+func (h newReleasesHandler) isRecording() bool {
+	return h.history != nil && config.CliConf.ReplayDirectory == ""
+}
+
+// skipReported removes the discographies whose new release was already reported to the profile in a previous run
+func (h newReleasesHandler) skipReported(profile config.Config, discographies []allmusic.Discography) []allmusic.Discography {
+	logger := log.WithFields(log.Fields{"Logger": "skipReported"})
+
+	remaining := make([]allmusic.Discography, 0, len(discographies))
+	for _, discography := range discographies {
+		reported, err := h.history.WasReported(profile.Title, discography)
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err}).Warn("Error checking history")
+		}
+		if reported {
+			logger.WithFields(log.Fields{"Artist": discography.Artist.Name, "Album": discography.NewestRelease.Title}).Info("Skipping album which was already reported")
+			continue
+		}
+		remaining = append(remaining, discography)
+	}
+	return remaining
+}
+
+func sortByScore(discographies []allmusic.Discography) {
+	sort.SliceStable(discographies, func(i, j int) bool {
+		return discographies[i].Score > discographies[j].Score
+	})
 }
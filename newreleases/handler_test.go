@@ -0,0 +1,347 @@
+package newreleases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/history"
+	"github.com/ynori7/music/view"
+)
+
+func Test_GenerateNewReleasesReport_Replay(t *testing.T) {
+	//given
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err, "There was an error parsing the release week")
+
+	config.CliConf = config.CliConfig{
+		OutputPath:      t.TempDir(),
+		ReplayDirectory: "testdata/replay",
+	}
+
+	conf := config.Config{Profile: config.Profile{
+		Title:      "metal",
+		MainGenres: []string{"Rock"},
+	}}
+	conf.SubGenres.FuzzyMatches = []string{"Metal"}
+
+	httpClient, err := allmusic.NewHttpClient(conf, config.CliConf)
+	require.NoError(t, err, "There was an error creating the http client")
+
+	//when
+	reports, err := NewReleasesHandler(
+		conf,
+		allmusic.NewReleasesClient(httpClient),
+		allmusic.NewDiscographyClient(httpClient, conf.Scoring),
+		nil,
+	).GenerateNewReleasesReports(context.Background(), week)
+
+	//then
+	require.NoError(t, err, "There was an error generating the report")
+	require.Len(t, reports, 1)
+	report := reports[0].Html
+	assert.Contains(t, report, "King Diamond")
+	assert.Contains(t, report, "Give Me Your Soul... Please")
+	assert.NotContains(t, report, "Deadly Lullabyes: Live", "Live albums should be filtered")
+	assert.NotContains(t, report, "Espejismo No. 9", "Uninteresting genres should be filtered")
+
+	assert.Equal(t, "metal-20200327.html", reports[0].HtmlFile)
+	saved, err := os.ReadFile(filepath.Join(config.CliConf.OutputPath, "metal-20200327.html"))
+	require.NoError(t, err, "The report should have been saved")
+	assert.Equal(t, report, string(saved))
+}
+
+func Test_GenerateNewReleasesReport_Formats(t *testing.T) {
+	//given
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err, "There was an error parsing the release week")
+
+	config.CliConf = config.CliConfig{
+		OutputPath:      t.TempDir(),
+		ReplayDirectory: "testdata/replay",
+		Formats:         []string{config.OutputJson, config.OutputCsv},
+	}
+
+	conf := config.Config{Profile: config.Profile{
+		Title:         "metal",
+		MainGenres:    []string{"Rock"},
+		Formats:       []string{config.OutputMarkdown, config.OutputCsv},
+		MarkdownStyle: config.MarkdownList,
+	}}
+	conf.SubGenres.FuzzyMatches = []string{"Metal"}
+
+	httpClient, err := allmusic.NewHttpClient(conf, config.CliConf)
+	require.NoError(t, err, "There was an error creating the http client")
+
+	//when
+	reports, err := NewReleasesHandler(
+		conf,
+		allmusic.NewReleasesClient(httpClient),
+		allmusic.NewDiscographyClient(httpClient, conf.Scoring),
+		nil,
+	).GenerateNewReleasesReports(context.Background(), week)
+
+	//then
+	require.NoError(t, err, "There was an error generating the report")
+	require.Len(t, reports, 1)
+	assert.Empty(t, reports[0].Html, "The html report shouldn't be generated")
+	assert.NoFileExists(t, filepath.Join(config.CliConf.OutputPath, "metal-20200327.html"))
+
+	saved, err := os.ReadFile(filepath.Join(config.CliConf.OutputPath, "metal-20200327.json"))
+	require.NoError(t, err, "The json report should have been saved")
+	var jsonReport view.JsonReport
+	require.NoError(t, json.Unmarshal(saved, &jsonReport))
+	assert.Equal(t, "20200327", jsonReport.From)
+	require.Len(t, jsonReport.Discographies, len(reports[0].Discographies))
+	assert.Equal(t, "King Diamond", jsonReport.Discographies[0].Artist.Name)
+	assert.Contains(t, jsonReport.Discographies[0].MatchedRules, "sub_genre: Heavy Metal")
+
+	saved, err = os.ReadFile(filepath.Join(config.CliConf.OutputPath, "metal-20200327.csv"))
+	require.NoError(t, err, "The csv report should have been saved")
+	assert.Contains(t, string(saved), "King Diamond,Give Me Your Soul... Please")
+
+	saved, err = os.ReadFile(filepath.Join(config.CliConf.OutputPath, "metal-20200327.md"))
+	require.NoError(t, err, "The markdown report of the profile should have been saved")
+	assert.True(t, strings.HasPrefix(string(saved), "1. "), "The markdown report should be a list")
+	assert.Contains(t, string(saved), "[King Diamond]")
+}
+
+func Test_GenerateNewReleasesReport_SkipsReported(t *testing.T) {
+	//given
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err, "There was an error parsing the release week")
+
+	config.CliConf = config.CliConfig{OutputPath: t.TempDir()}
+
+	conf := config.Config{Profile: config.Profile{
+		Title:      "metal",
+		MainGenres: []string{"Rock"},
+	}}
+	conf.SubGenres.FuzzyMatches = []string{"Metal"}
+
+	//the replay client is used directly because nothing is remembered in replay mode
+	httpClient := allmusic.NewReplayClient("testdata/replay")
+
+	historyStore, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err, "There was an error opening the history")
+	defer historyStore.Close()
+
+	handler := NewReleasesHandler(
+		conf,
+		allmusic.NewReleasesClient(httpClient),
+		allmusic.NewDiscographyClient(httpClient, conf.Scoring),
+		historyStore,
+	)
+
+	//when
+	first, err := handler.GenerateNewReleasesReports(context.Background(), week)
+	require.NoError(t, err, "There was an error generating the first report")
+	failed := NewRunSummary(time.Now(), first)
+	failed.AddDelivery("email", "me@mysite.com", "metal", week, week, errors.New("connection refused"))
+	handler.RecordDelivered(first, failed)
+
+	second, err := handler.GenerateNewReleasesReports(context.Background(), week)
+	require.NoError(t, err, "There was an error generating the second report")
+	delivered := NewRunSummary(time.Now(), second)
+	delivered.AddDelivery("email", "me@mysite.com", "metal", week, week, errors.New("connection refused"))
+	delivered.AddDelivery("slack", "hooks.slack.com", "metal", week, week, nil)
+	handler.RecordDelivered(second, delivered)
+
+	third, err := handler.GenerateNewReleasesReports(context.Background(), week)
+	require.NoError(t, err, "There was an error generating the third report")
+	config.CliConf.Resend = true
+	resent, err := handler.GenerateNewReleasesReports(context.Background(), week)
+	require.NoError(t, err, "There was an error generating the resent report")
+
+	//then
+	assert.Contains(t, first[0].Html, "Give Me Your Soul... Please")
+	assert.Contains(t, second[0].Html, "Give Me Your Soul... Please", "The album wasn't delivered, so it should be reported again")
+	assert.NotContains(t, third[0].Html, "Give Me Your Soul... Please", "The album was already reported")
+	assert.Contains(t, resent[0].Html, "Give Me Your Soul... Please", "The album should be resent")
+}
+
+func Test_RecordDelivered_Replay(t *testing.T) {
+	//given
+	config.CliConf = config.CliConfig{OutputPath: t.TempDir(), ReplayDirectory: "testdata/replay"}
+
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err, "There was an error parsing the release week")
+
+	historyStore, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err, "There was an error opening the history")
+	defer historyStore.Close()
+
+	discography := allmusic.Discography{
+		Artist:        allmusic.Artist{Name: "King Diamond"},
+		NewestRelease: allmusic.Album{Title: "Give Me Your Soul... Please"},
+	}
+	reports := []Report{{
+		Config:        config.Config{Profile: config.Profile{Title: "metal"}},
+		From:          week,
+		To:            week,
+		Discographies: []allmusic.Discography{discography},
+		weeks:         map[allmusic.ReleaseWeek][]allmusic.Discography{week: {discography}},
+	}}
+
+	//when
+	NewReleasesHandler(config.Config{}, nil, nil, historyStore).RecordDelivered(reports, NewRunSummary(time.Now(), reports))
+
+	//then
+	reported, err := historyStore.WasReported("metal", discography)
+	require.NoError(t, err)
+	assert.False(t, reported, "Replayed reports shouldn't be remembered")
+}
+
+func Test_GenerateNewReleasesReports_Profiles(t *testing.T) {
+	//given
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err, "There was an error parsing the release week")
+
+	config.CliConf = config.CliConfig{
+		OutputPath:      t.TempDir(),
+		ReplayDirectory: "testdata/replay",
+	}
+
+	conf := config.Config{}
+	err = conf.Parse([]byte(`profiles:
+  - title: "metal"
+    main_genres: ["Rock"]
+    sub_genres:
+      fuzzy_matches: ["Metal"]
+  - title: "latin"
+    main_genres: ["Latin"]`))
+	require.NoError(t, err, "There was an error parsing the config")
+
+	httpClient, err := allmusic.NewHttpClient(conf, config.CliConf)
+	require.NoError(t, err, "There was an error creating the http client")
+
+	source := &countingReleaseSource{NewReleaseSource: allmusic.NewReleasesClient(httpClient)}
+
+	//when
+	reports, err := NewReleasesHandler(
+		conf,
+		source,
+		allmusic.NewDiscographyClient(httpClient, conf.Scoring),
+		nil,
+	).GenerateNewReleasesReports(context.Background(), week)
+
+	//then
+	require.NoError(t, err, "There was an error generating the reports")
+	assert.Equal(t, 1, source.calls, "The new releases should only be fetched once")
+	require.Len(t, reports, 2)
+	assert.Equal(t, "metal", reports[0].Config.Title)
+	assert.Contains(t, reports[0].Html, "Give Me Your Soul... Please")
+	assert.Equal(t, "latin", reports[1].Config.Title)
+	assert.NotContains(t, reports[1].Html, "Give Me Your Soul... Please")
+
+	for _, file := range []string{"metal-20200327.html", "latin-20200327.html"} {
+		_, err := os.Stat(filepath.Join(config.CliConf.OutputPath, file))
+		assert.NoError(t, err, "The report should have been saved")
+	}
+}
+
+type fakeReleaseSource map[string][]allmusic.NewRelease
+
+type countingReleaseSource struct {
+	allmusic.NewReleaseSource
+	calls int
+}
+
+func (c *countingReleaseSource) GetNewReleasesForWeek(ctx context.Context, week allmusic.ReleaseWeek) ([]allmusic.NewRelease, error) {
+	c.calls++
+	return c.NewReleaseSource.GetNewReleasesForWeek(ctx, week)
+}
+
+func (f fakeReleaseSource) GetNewReleasesForWeek(_ context.Context, week allmusic.ReleaseWeek) ([]allmusic.NewRelease, error) {
+	releases, ok := f[week.String()]
+	if !ok {
+		return nil, fmt.Errorf("no releases for week %s", week)
+	}
+	return releases, nil
+}
+
+func Test_GenerateBackfillReports(t *testing.T) {
+	kingDiamond := allmusic.NewRelease{
+		ArtistName:    "King Diamond",
+		ArtistLink:    "https://www.allmusic.com/artist/king-diamond-mn0000770007",
+		NewAlbumTitle: "Give Me Your Soul... Please",
+		Genres:        []string{"Pop/Rock"},
+	}
+	source := fakeReleaseSource{
+		"20200320": {kingDiamond},
+		"20200327": {kingDiamond},
+	}
+
+	testcases := map[string]struct {
+		Combine       bool
+		ExpectedFiles []string
+	}{
+		"One report per week": {
+			Combine:       false,
+			ExpectedFiles: []string{"metal-20200320.html", "metal-20200327.html"},
+		},
+		"Combined report": {
+			Combine:       true,
+			ExpectedFiles: []string{"metal-20200320-20200403.html"},
+		},
+	}
+
+	from, err := allmusic.ParseReleaseWeek("20200320")
+	require.NoError(t, err, "There was an error parsing the release week")
+	to, err := allmusic.ParseReleaseWeek("20200403")
+	require.NoError(t, err, "There was an error parsing the release week")
+	weeks, err := allmusic.ReleaseWeeksBetween(from, to)
+	require.NoError(t, err, "There was an error computing the release weeks")
+
+	for testcase, testdata := range testcases {
+		//given
+		config.CliConf = config.CliConfig{
+			OutputPath:      t.TempDir(),
+			ReplayDirectory: "testdata/replay",
+		}
+
+		conf := config.Config{Profile: config.Profile{Title: "metal", MainGenres: []string{"Rock"}}}
+		conf.SubGenres.FuzzyMatches = []string{"Metal"}
+
+		httpClient, err := allmusic.NewHttpClient(conf, config.CliConf)
+		require.NoError(t, err, testcase)
+
+		handler := NewReleasesHandler(
+			conf,
+			source,
+			allmusic.NewMemoizingDiscographyProvider(allmusic.NewDiscographyClient(httpClient, conf.Scoring)),
+			nil,
+		)
+
+		//when
+		reports, err := handler.GenerateBackfillReports(context.Background(), weeks, testdata.Combine)
+
+		//then
+		require.NoError(t, err, testcase)
+		require.Len(t, reports, len(testdata.ExpectedFiles), testcase)
+		for i, file := range testdata.ExpectedFiles {
+			saved, err := os.ReadFile(filepath.Join(config.CliConf.OutputPath, file))
+			require.NoError(t, err, testcase+": the report should have been saved")
+			assert.Equal(t, reports[i].Html, string(saved), testcase)
+			assert.Equal(t, 1, strings.Count(reports[i].Html, "Give Me Your Soul... Please"), testcase+": the album should be reported once")
+		}
+	}
+}
+
+func Test_GenerateBackfillReports_NoWeeks(t *testing.T) {
+	//when
+	_, err := NewReleasesHandler(config.Config{}, fakeReleaseSource{}, nil, nil).GenerateBackfillReports(context.Background(), nil, false)
+
+	//then
+	assert.Error(t, err)
+}
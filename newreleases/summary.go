@@ -0,0 +1,94 @@
+package newreleases
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/ynori7/music/allmusic"
+)
+
+// RunSummary describes what a run generated and whether the reports were delivered
+type RunSummary struct {
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Reports    []ReportSummary   `json:"reports"`
+	Deliveries []DeliverySummary `json:"deliveries"`
+}
+
+type ReportSummary struct {
+	Profile  string `json:"profile"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Releases int    `json:"releases"`
+}
+
+// DeliverySummary is the outcome of sending a report by email or with a notifier
+type DeliverySummary struct {
+	Channel   string `json:"channel"` //e.g. email or webhook
+	Target    string `json:"target"`  //the recipient or host
+	Profile   string `json:"profile"`
+	From      string `json:"from"` //the release weeks of the delivered report
+	To        string `json:"to"`
+	Delivered bool   `json:"delivered"`
+	Error     string `json:"error,omitempty"`
+}
+
+func NewRunSummary(startedAt time.Time, reports []Report) RunSummary {
+	summary := RunSummary{
+		StartedAt:  startedAt,
+		Reports:    make([]ReportSummary, 0, len(reports)),
+		Deliveries: make([]DeliverySummary, 0),
+	}
+	for _, report := range reports {
+		summary.Reports = append(summary.Reports, ReportSummary{
+			Profile:  report.Config.Title,
+			From:     report.From.String(),
+			To:       report.To.String(),
+			Releases: len(report.Discographies),
+		})
+	}
+	return summary
+}
+
+// AddDelivery records the outcome of a delivery of the profile's report of the release weeks
+func (s *RunSummary) AddDelivery(channel, target, profile string, from, to allmusic.ReleaseWeek, err error) {
+	delivery := DeliverySummary{
+		Channel:   channel,
+		Target:    target,
+		Profile:   profile,
+		From:      from.String(),
+		To:        to.String(),
+		Delivered: err == nil,
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	s.Deliveries = append(s.Deliveries, delivery)
+}
+
+// Delivered returns whether the report reached at least one recipient. This is also the case when there weren't any
+// deliveries of the report, because then the saved report is all there is.
+func (s RunSummary) Delivered(report Report) bool {
+	attempted := false
+	for _, delivery := range s.Deliveries {
+		if delivery.Profile != report.Config.Title || delivery.From != report.From.String() || delivery.To != report.To.String() {
+			continue
+		}
+		if delivery.Delivered {
+			return true
+		}
+		attempted = true
+	}
+	return !attempted
+}
+
+// Save finishes the summary and writes it as json to the path
+func (s RunSummary) Save(path string) error {
+	s.FinishedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,74 @@
+package newreleases
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+)
+
+func Test_RunSummary(t *testing.T) {
+	//given
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err, "There was an error parsing the release week")
+
+	startedAt := time.Date(2020, 3, 27, 14, 0, 0, 0, time.UTC)
+	summary := NewRunSummary(startedAt, []Report{{
+		Config:        config.Config{Profile: config.Profile{Title: "metal"}},
+		From:          week,
+		To:            week,
+		Discographies: make([]allmusic.Discography, 3),
+	}})
+	path := filepath.Join(t.TempDir(), "summary.json")
+
+	//when
+	summary.AddDelivery("email", "me@mysite.com", "metal", week, week, nil)
+	summary.AddDelivery("webhook", "hooks.mysite.com", "metal", week, week, errors.New("status code error: 502 502 Bad Gateway"))
+	require.NoError(t, summary.Save(path))
+
+	//then
+	data, err := os.ReadFile(path)
+	require.NoError(t, err, "The summary should have been saved")
+
+	var saved RunSummary
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.True(t, saved.StartedAt.Equal(startedAt))
+	assert.True(t, saved.FinishedAt.After(startedAt))
+	assert.Equal(t, []ReportSummary{{Profile: "metal", From: "20200327", To: "20200327", Releases: 3}}, saved.Reports)
+	assert.Equal(t, []DeliverySummary{
+		{Channel: "email", Target: "me@mysite.com", Profile: "metal", From: "20200327", To: "20200327", Delivered: true},
+		{Channel: "webhook", Target: "hooks.mysite.com", Profile: "metal", From: "20200327", To: "20200327", Error: "status code error: 502 502 Bad Gateway"},
+	}, saved.Deliveries)
+}
+
+func Test_RunSummary_Delivered(t *testing.T) {
+	//given
+	first, err := allmusic.ParseReleaseWeek("20200320")
+	require.NoError(t, err, "There was an error parsing the release week")
+	second := first.Next()
+
+	metal := config.Config{Profile: config.Profile{Title: "metal"}}
+	reports := []Report{
+		{Config: metal, From: first, To: first},
+		{Config: metal, From: second, To: second},
+		{Config: config.Config{Profile: config.Profile{Title: "rock"}}, From: first, To: first},
+	}
+	summary := NewRunSummary(time.Now(), reports)
+
+	//when
+	summary.AddDelivery("email", "me@mysite.com", "metal", first, first, errors.New("connection refused"))
+	summary.AddDelivery("slack", "hooks.slack.com", "metal", first, first, nil)
+	summary.AddDelivery("email", "me@mysite.com", "metal", second, second, errors.New("connection refused"))
+
+	//then
+	assert.True(t, summary.Delivered(reports[0]), "One of the deliveries of the week succeeded")
+	assert.False(t, summary.Delivered(reports[1]), "Every delivery of the week failed")
+	assert.True(t, summary.Delivered(reports[2]), "A report which isn't sent anywhere counts as delivered")
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/view"
+)
+
+const maxDiscordEmbeds = 10 //discord rejects messages with more embeds
+
+// DiscordNotifier posts the top releases to a Discord webhook with an embed per release
+type DiscordNotifier struct {
+	httpClient HttpClient
+	webhook    config.ChatWebhook
+}
+
+func NewDiscordNotifier(httpClient HttpClient, webhook config.ChatWebhook) DiscordNotifier {
+	return DiscordNotifier{
+		httpClient: httpClient,
+		webhook:    webhook,
+	}
+}
+
+func (n DiscordNotifier) Name() string {
+	return "discord"
+}
+
+func (n DiscordNotifier) Target() string {
+	return host(n.webhook.Url)
+}
+
+func (n DiscordNotifier) IsSubscribedTo(profile string) bool {
+	return n.webhook.IsSubscribedTo(profile)
+}
+
+func (n DiscordNotifier) Notify(ctx context.Context, report Report) error {
+	return sendJson(ctx, n.httpClient, http.MethodPost, n.webhook.Url, n.buildMessage(report), nil)
+}
+
+type discordMessage struct {
+	Content string         `json:"content"`
+	Embeds  []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string            `json:"title"`
+	Url         string            `json:"url,omitempty"`
+	Description string            `json:"description"`
+	Author      *discordAuthor    `json:"author,omitempty"`
+	Thumbnail   *discordThumbnail `json:"thumbnail,omitempty"`
+}
+
+type discordAuthor struct {
+	Name string `json:"name"`
+	Url  string `json:"url,omitempty"`
+}
+
+type discordThumbnail struct {
+	Url string `json:"url"`
+}
+
+func (n DiscordNotifier) buildMessage(report Report) discordMessage {
+	message := discordMessage{
+		Content: "**" + title(report) + "**",
+		Embeds:  make([]discordEmbed, 0),
+	}
+
+	top := n.webhook.Top
+	if top <= 0 {
+		top = defaultTop
+	}
+	discographies := topDiscographies(report, min(top, maxDiscordEmbeds))
+	if len(discographies) == 0 {
+		message.Content += "\nThere are no interesting new releases."
+		return message
+	}
+
+	for _, d := range discographies {
+		embed := discordEmbed{
+			Title:       d.NewestRelease.Title,
+			Url:         d.NewestRelease.Link,
+			Description: discordDescription(d),
+			Author:      &discordAuthor{Name: d.Artist.Name, Url: d.Artist.Link},
+		}
+		if d.NewestRelease.Image != "" {
+			embed.Thumbnail = &discordThumbnail{Url: d.NewestRelease.Image}
+		}
+		message.Embeds = append(message.Embeds, embed)
+	}
+	return message
+}
+
+func discordDescription(d allmusic.Discography) string {
+	lines := make([]string, 0)
+	if len(d.Artist.Genres) > 0 {
+		lines = append(lines, strings.Join(d.Artist.Genres, ", "))
+	}
+	lines = append(lines, fmt.Sprintf("%s · Score: %d / 100", view.StarRating(d.NewestRelease.Rating), d.Score))
+	return strings.Join(lines, "\n")
+}
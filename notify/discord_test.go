@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+)
+
+func Test_DiscordNotifier(t *testing.T) {
+	//given
+	server := newChatServer(t, http.StatusNoContent)
+	notifier := NewDiscordNotifier(http.DefaultClient, config.ChatWebhook{Url: server.URL})
+
+	//when
+	err := notifier.Notify(context.Background(), testReport(t))
+
+	//then
+	require.NoError(t, err)
+	require.Len(t, server.bodies, 1)
+
+	body := server.bodies[0]
+	assert.Equal(t, "**metal: Newest releases from the week of 2020-03-27**", body["content"])
+
+	embeds := body["embeds"].([]any)
+	require.Len(t, embeds, 2)
+	first := embeds[0].(map[string]any)
+	assert.Equal(t, "The Institute", first["title"])
+	assert.Equal(t, "https://www.allmusic.com/album/the-institute", first["url"])
+	assert.Equal(t, "Heavy Metal\n★★★★½ · Score: 90 / 100", first["description"])
+	assert.Equal(t, "King Diamond", first["author"].(map[string]any)["name"])
+	assert.Equal(t, "https://cdn.allmusic.com/the-institute.jpg", first["thumbnail"].(map[string]any)["url"])
+	assert.NotContains(t, embeds[1].(map[string]any), "thumbnail", "Releases without a cover have no thumbnail")
+}
+
+func Test_DiscordNotifier_EmbedLimit(t *testing.T) {
+	//given
+	report := testReport(t)
+	report.Discographies = make([]allmusic.Discography, 15)
+	notifier := NewDiscordNotifier(http.DefaultClient, config.ChatWebhook{Channel: config.Channel{Top: 20}})
+
+	//when
+	message := notifier.buildMessage(report)
+
+	//then
+	assert.Len(t, message.Embeds, maxDiscordEmbeds)
+}
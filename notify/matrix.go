@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/view"
+)
+
+var matrixTemplate = template.Must(template.New("matrix").Funcs(template.FuncMap{
+	"inc":   func(i int) int { return i + 1 },
+	"join":  strings.Join,
+	"stars": view.StarRating,
+}).Parse(matrixHtml))
+
+var matrixTransactions atomic.Int64 //makes the transaction ids unique within the run
+
+// MatrixNotifier posts the top releases to a Matrix room
+type MatrixNotifier struct {
+	httpClient HttpClient
+	room       config.MatrixRoom
+}
+
+func NewMatrixNotifier(httpClient HttpClient, room config.MatrixRoom) MatrixNotifier {
+	return MatrixNotifier{
+		httpClient: httpClient,
+		room:       room,
+	}
+}
+
+func (n MatrixNotifier) Name() string {
+	return "matrix"
+}
+
+func (n MatrixNotifier) Target() string {
+	return n.room.RoomId
+}
+
+func (n MatrixNotifier) IsSubscribedTo(profile string) bool {
+	return n.room.IsSubscribedTo(profile)
+}
+
+func (n MatrixNotifier) Notify(ctx context.Context, report Report) error {
+	message, err := n.buildMessage(report)
+	if err != nil {
+		return err
+	}
+
+	//the transaction id only has to be unique for the access token
+	transactionId := fmt.Sprintf("music-%d-%d", time.Now().UnixNano(), matrixTransactions.Add(1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(n.room.Homeserver, "/"), url.PathEscape(n.room.RoomId), transactionId)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+n.room.AccessToken)
+	return sendJson(ctx, n.httpClient, http.MethodPut, endpoint, message, header)
+}
+
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"` //the plain text fallback
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+func (n MatrixNotifier) buildMessage(report Report) (matrixMessage, error) {
+	discographies := topDiscographies(report, n.room.Top)
+
+	body, err := view.NewMarkdownTemplate(discographies).AsList().WithoutCovers().ExecuteMarkdownTemplate()
+	if err != nil {
+		return matrixMessage{}, err
+	}
+
+	var formatted bytes.Buffer
+	err = matrixTemplate.Execute(&formatted, struct {
+		Title         string
+		Discographies []allmusic.Discography
+	}{Title: title(report), Discographies: discographies})
+	if err != nil {
+		return matrixMessage{}, err
+	}
+
+	return matrixMessage{
+		MsgType:       "m.text",
+		Body:          title(report) + "\n\n" + body,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted.String(),
+	}, nil
+}
+
+const matrixHtml = `<h4>{{ .Title }}</h4>
+{{ if .Discographies }}<ol>
+{{ range .Discographies }}<li><b>{{ if .Artist.Link }}<a href="{{ .Artist.Link }}">{{ .Artist.Name }}</a>{{ else }}{{ .Artist.Name }}{{ end }} – {{ if .NewestRelease.Link }}<a href="{{ .NewestRelease.Link }}">{{ .NewestRelease.Title }}</a>{{ else }}{{ .NewestRelease.Title }}{{ end }}</b><br>
+{{ if .Artist.Genres }}{{ join .Artist.Genres ", " }}<br>
+{{ end }}{{ stars .NewestRelease.Rating }} · Score: {{ .Score }} / 100</li>
+{{ end }}</ol>{{ else }}<p>There are no interesting new releases.</p>{{ end }}`
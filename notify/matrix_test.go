@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/config"
+)
+
+func Test_MatrixNotifier(t *testing.T) {
+	//given
+	server := newChatServer(t, http.StatusOK)
+	notifier := NewMatrixNotifier(http.DefaultClient, config.MatrixRoom{
+		Homeserver:  server.URL + "/",
+		RoomId:      "!abcdef:matrix.org",
+		AccessToken: "secret",
+	})
+
+	//when
+	err := notifier.Notify(context.Background(), testReport(t))
+	require.NoError(t, err)
+	err = notifier.Notify(context.Background(), testReport(t))
+	require.NoError(t, err)
+
+	//then
+	require.Len(t, server.requests, 2)
+	request := server.requests[0]
+	assert.Equal(t, http.MethodPut, request.Method)
+	assert.True(t, strings.HasPrefix(request.URL.EscapedPath(), "/_matrix/client/v3/rooms/%21abcdef:matrix.org/send/m.room.message/"), request.URL.EscapedPath())
+	assert.NotEqual(t, request.URL.Path, server.requests[1].URL.Path, "Each message needs its own transaction id")
+	assert.Equal(t, "Bearer secret", request.Header.Get("Authorization"))
+
+	body := server.bodies[0]
+	assert.Equal(t, "m.text", body["msgtype"])
+	assert.Equal(t, "org.matrix.custom.html", body["format"])
+	assert.Contains(t, body["body"], "1. **[King Diamond](https://www.allmusic.com/artist/king-diamond) – [The Institute](https://www.allmusic.com/album/the-institute)**")
+	assert.NotContains(t, body["body"], "![cover]", "The plain text shouldn't contain images")
+	assert.Contains(t, body["formatted_body"], `<a href="https://www.allmusic.com/album/the-institute">The Institute</a>`)
+	assert.Contains(t, body["formatted_body"], "Ghost – Impera")
+}
@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/retry"
+	"github.com/ynori7/music/view"
+)
+
+const defaultTop = 5
+
+// HttpClient sends the requests to the chat services
+type HttpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Notifier posts reports to a channel
+type Notifier interface {
+	Name() string   //the kind of notifier, e.g. slack
+	Target() string //where the reports are posted to, without any secrets
+	IsSubscribedTo(profile string) bool
+	Notify(ctx context.Context, report Report) error
+}
+
+// Report contains the interesting discographies of a profile, sorted by score
+type Report struct {
+	Profile       string
+	From          allmusic.ReleaseWeek
+	To            allmusic.ReleaseWeek
+	Discographies []allmusic.Discography
+	HtmlFile      string //the name of the saved html report in the output directory, if there is one
+}
+
+// Delivery is the outcome of posting a report with a notifier
+type Delivery struct {
+	Notifier string
+	Target   string
+	Profile  string
+	From     allmusic.ReleaseWeek
+	To       allmusic.ReleaseWeek
+	Err      error
+}
+
+// NewNotifiers creates the notifiers which are configured
+func NewNotifiers(conf config.Config, httpClient HttpClient) []Notifier {
+	notifiers := make([]Notifier, 0)
+	for _, webhook := range conf.Notifiers.Slack {
+		notifiers = append(notifiers, NewSlackNotifier(httpClient, webhook))
+	}
+	for _, webhook := range conf.Notifiers.Discord {
+		notifiers = append(notifiers, NewDiscordNotifier(httpClient, webhook))
+	}
+	for _, room := range conf.Notifiers.Matrix {
+		notifiers = append(notifiers, NewMatrixNotifier(httpClient, room))
+	}
+	for _, push := range conf.Notifiers.Push {
+		switch push.Service {
+		case config.PushNtfy:
+			notifiers = append(notifiers, NewNtfyNotifier(httpClient, push))
+		case config.PushGotify:
+			notifiers = append(notifiers, NewGotifyNotifier(httpClient, push))
+		}
+	}
+	for _, webhook := range conf.Notifiers.Webhooks {
+		notifiers = append(notifiers, NewWebhookNotifier(retry.NewClient(httpClient, conf.Notifiers.Retry), webhook))
+	}
+	return notifiers
+}
+
+// NotifyAll posts the reports with each notifier which is subscribed to their profile. Each delivery may take up to
+// the timeout (unless it's zero), so a failed or hanging delivery doesn't stop the others. The outcome of each one is
+// returned.
+func NotifyAll(ctx context.Context, notifiers []Notifier, reports []Report, timeout time.Duration) []Delivery {
+	deliveries := make([]Delivery, 0)
+	for _, notifier := range notifiers {
+		for _, report := range reports {
+			if !notifier.IsSubscribedTo(report.Profile) {
+				continue
+			}
+			deliveries = append(deliveries, Delivery{
+				Notifier: notifier.Name(),
+				Target:   notifier.Target(),
+				Profile:  report.Profile,
+				From:     report.From,
+				To:       report.To,
+				Err:      notify(ctx, notifier, report, timeout),
+			})
+		}
+	}
+	return deliveries
+}
+
+func notify(ctx context.Context, notifier Notifier, report Report, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return notifier.Notify(ctx, report)
+}
+
+// host returns the host of the url, which identifies a webhook without revealing the token in its path
+func host(webhookUrl string) string {
+	u, err := url.Parse(webhookUrl)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// title is the headline of the posted report
+func title(report Report) string {
+	return report.Profile + ": " + view.GetBackfillSubjectLine(report.From, report.To)
+}
+
+// topDiscographies returns the best releases of the report
+func topDiscographies(report Report, top int) []allmusic.Discography {
+	if top <= 0 {
+		top = defaultTop
+	}
+	if top < len(report.Discographies) {
+		return report.Discographies[:top]
+	}
+	return report.Discographies
+}
+
+// sendJson sends the payload and fails unless the response is successful
+func sendJson(ctx context.Context, httpClient HttpClient, method, url string, payload any, header http.Header) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return send(ctx, httpClient, method, url, body, header)
+}
+
+// send sends the json body and fails unless the response is successful
+func send(ctx context.Context, httpClient HttpClient, method, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("status code error: %d %s: %s", res.StatusCode, res.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+)
+
+// chatServer is a local stand-in for the chat services which records the requests
+type chatServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests []*http.Request
+	bodies   []map[string]any
+}
+
+func newChatServer(t *testing.T, status int) *chatServer {
+	s := &chatServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		var payload map[string]any
+		assert.NoError(t, json.Unmarshal(body, &payload), "The payload should be json")
+
+		s.mutex.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, payload)
+		s.mutex.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func testReport(t *testing.T) Report {
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err)
+
+	return Report{
+		Profile: "metal",
+		From:    week,
+		To:      week,
+		Discographies: []allmusic.Discography{
+			{
+				Artist:        allmusic.Artist{Name: "King Diamond", Genres: []string{"Heavy Metal"}, Link: "https://www.allmusic.com/artist/king-diamond"},
+				NewestRelease: allmusic.Album{Title: "The Institute", Link: "https://www.allmusic.com/album/the-institute", Image: "https://cdn.allmusic.com/the-institute.jpg", Rating: 9},
+				Score:         90,
+			},
+			{
+				Artist:        allmusic.Artist{Name: "Ghost"},
+				NewestRelease: allmusic.Album{Title: "Impera", Rating: 8},
+				Score:         60,
+			},
+		},
+	}
+}
+
+func Test_NotifyAll(t *testing.T) {
+	//given
+	working := newChatServer(t, http.StatusOK)
+	failing := newChatServer(t, http.StatusInternalServerError)
+
+	conf := config.Config{Notifiers: config.Notifiers{
+		Slack: []config.ChatWebhook{
+			{Url: failing.URL},
+			{Url: working.URL, Channel: config.Channel{Profiles: []string{"jazz"}}},
+		},
+		Discord: []config.ChatWebhook{{Url: working.URL}},
+	}}
+	notifiers := NewNotifiers(conf, http.DefaultClient)
+
+	//when
+	deliveries := NotifyAll(context.Background(), notifiers, []Report{testReport(t)}, 0)
+
+	//then
+	require.Len(t, deliveries, 2, "The notifier which isn't subscribed to the profile should be skipped")
+	assert.Equal(t, "slack", deliveries[0].Notifier)
+	assert.Error(t, deliveries[0].Err, "The failing notifier should report its error")
+	assert.Equal(t, "discord", deliveries[1].Notifier)
+	assert.NoError(t, deliveries[1].Err, "The failure shouldn't affect the other notifiers")
+	assert.Equal(t, "metal", deliveries[1].Profile)
+	assert.Len(t, working.requests, 1)
+}
+
+func Test_NotifyAll_Timeout(t *testing.T) {
+	//given
+	working := newChatServer(t, http.StatusOK)
+	notifiers := []Notifier{
+		hangingNotifier{},
+		NewSlackNotifier(http.DefaultClient, config.ChatWebhook{Url: working.URL}),
+	}
+
+	//when
+	deliveries := NotifyAll(context.Background(), notifiers, []Report{testReport(t)}, 50*time.Millisecond)
+
+	//then
+	require.Len(t, deliveries, 2)
+	assert.ErrorIs(t, deliveries[0].Err, context.DeadlineExceeded)
+	assert.NoError(t, deliveries[1].Err, "Each delivery should get its own timeout")
+}
+
+// hangingNotifier never responds, like a webhook which doesn't answer
+type hangingNotifier struct{}
+
+func (hangingNotifier) Name() string               { return "hanging" }
+func (hangingNotifier) Target() string             { return "" }
+func (hangingNotifier) IsSubscribedTo(string) bool { return true }
+func (hangingNotifier) Notify(ctx context.Context, _ Report) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ynori7/music/config"
+)
+
+const pushReleases = 3 //how many releases are named in a push notification
+
+// NtfyNotifier sends a short summary of the report to an ntfy topic
+type NtfyNotifier struct {
+	httpClient HttpClient
+	push       config.Push
+}
+
+func NewNtfyNotifier(httpClient HttpClient, push config.Push) NtfyNotifier {
+	return NtfyNotifier{
+		httpClient: httpClient,
+		push:       push,
+	}
+}
+
+func (n NtfyNotifier) Name() string {
+	return config.PushNtfy
+}
+
+func (n NtfyNotifier) Target() string {
+	return host(n.push.Url) + "/" + n.push.Topic
+}
+
+func (n NtfyNotifier) IsSubscribedTo(profile string) bool {
+	return n.push.IsSubscribedTo(profile)
+}
+
+type ntfyMessage struct {
+	Topic    string `json:"topic"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"` //from 1 (min) to 5 (max)
+	Click    string `json:"click,omitempty"`
+}
+
+func (n NtfyNotifier) Notify(ctx context.Context, report Report) error {
+	message := ntfyMessage{
+		Topic:    n.push.Topic,
+		Title:    title(report),
+		Message:  pushSummary(report),
+		Priority: pushPriority(report),
+		Click:    reportUrl(n.push.ReportsUrl, report),
+	}
+
+	header := http.Header{}
+	if n.push.Token != "" {
+		header.Set("Authorization", "Bearer "+n.push.Token)
+	}
+	//messages are published as json to the root of the server rather than to the url of the topic
+	return sendJson(ctx, n.httpClient, http.MethodPost, strings.TrimSuffix(n.push.Url, "/"), message, header)
+}
+
+// GotifyNotifier sends a short summary of the report to a Gotify server
+type GotifyNotifier struct {
+	httpClient HttpClient
+	push       config.Push
+}
+
+func NewGotifyNotifier(httpClient HttpClient, push config.Push) GotifyNotifier {
+	return GotifyNotifier{
+		httpClient: httpClient,
+		push:       push,
+	}
+}
+
+func (n GotifyNotifier) Name() string {
+	return config.PushGotify
+}
+
+func (n GotifyNotifier) Target() string {
+	return host(n.push.Url)
+}
+
+func (n GotifyNotifier) IsSubscribedTo(profile string) bool {
+	return n.push.IsSubscribedTo(profile)
+}
+
+type gotifyMessage struct {
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+	Priority int            `json:"priority"` //from 0 to 10, where 8 and above are high
+	Extras   map[string]any `json:"extras,omitempty"`
+}
+
+func (n GotifyNotifier) Notify(ctx context.Context, report Report) error {
+	message := gotifyMessage{
+		Title:    title(report),
+		Message:  pushSummary(report),
+		Priority: pushPriority(report)*2 - 1,
+	}
+	if click := reportUrl(n.push.ReportsUrl, report); click != "" {
+		message.Extras = map[string]any{
+			"client::notification": map[string]any{"click": map[string]string{"url": click}},
+		}
+	}
+
+	header := http.Header{}
+	header.Set("X-Gotify-Key", n.push.Token)
+	return sendJson(ctx, n.httpClient, http.MethodPost, strings.TrimSuffix(n.push.Url, "/")+"/message", message, header)
+}
+
+// pushSummary names the best releases, e.g. "5 new releases: Ghost – Impera (90), ..."
+func pushSummary(report Report) string {
+	if len(report.Discographies) == 0 {
+		return "There are no interesting new releases."
+	}
+
+	releases := make([]string, 0, pushReleases)
+	for _, d := range topDiscographies(report, pushReleases) {
+		releases = append(releases, fmt.Sprintf("%s – %s (%d)", d.Artist.Name, d.NewestRelease.Title, d.Score))
+	}
+
+	summary := fmt.Sprintf("%d new releases: %s", len(report.Discographies), strings.Join(releases, ", "))
+	if len(report.Discographies) == 1 {
+		summary = "1 new release: " + releases[0]
+	}
+	if more := len(report.Discographies) - len(releases); more > 0 {
+		summary += fmt.Sprintf(" and %d more", more)
+	}
+	return summary
+}
+
+// pushPriority is higher the better the top release is, from 1 when there are no releases to 5 for a score of 90
+func pushPriority(report Report) int {
+	topScore := -1
+	for _, d := range report.Discographies {
+		topScore = max(topScore, d.Score)
+	}
+
+	switch {
+	case topScore < 0:
+		return 1
+	case topScore >= 90:
+		return 5
+	case topScore >= 75:
+		return 4
+	case topScore >= 50:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// reportUrl returns the link to the published html report or an empty string if it isn't published
+func reportUrl(reportsUrl string, report Report) string {
+	if reportsUrl == "" || report.HtmlFile == "" {
+		return ""
+	}
+	return strings.TrimSuffix(reportsUrl, "/") + "/" + url.PathEscape(report.HtmlFile)
+}
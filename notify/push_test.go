@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+)
+
+func Test_NtfyNotifier(t *testing.T) {
+	//given
+	server := newChatServer(t, http.StatusOK)
+	notifier := NewNtfyNotifier(http.DefaultClient, config.Push{
+		Service:    config.PushNtfy,
+		Url:        server.URL + "/",
+		Topic:      "music",
+		Token:      "secret",
+		ReportsUrl: "https://music.mysite.com/reports/",
+	})
+	report := testReport(t)
+	report.HtmlFile = "metal-20200327.html"
+
+	//when
+	err := notifier.Notify(context.Background(), report)
+
+	//then
+	require.NoError(t, err)
+	require.Len(t, server.requests, 1)
+	assert.Equal(t, "/", server.requests[0].URL.Path)
+	assert.Equal(t, "Bearer secret", server.requests[0].Header.Get("Authorization"))
+	assert.Equal(t, map[string]any{
+		"topic":    "music",
+		"title":    "metal: Newest releases from the week of 2020-03-27",
+		"message":  "2 new releases: King Diamond – The Institute (90), Ghost – Impera (60)",
+		"priority": float64(5),
+		"click":    "https://music.mysite.com/reports/metal-20200327.html",
+	}, server.bodies[0])
+}
+
+func Test_GotifyNotifier(t *testing.T) {
+	//given
+	server := newChatServer(t, http.StatusOK)
+	notifier := NewGotifyNotifier(http.DefaultClient, config.Push{
+		Service:    config.PushGotify,
+		Url:        server.URL,
+		Token:      "app-token",
+		ReportsUrl: "https://music.mysite.com",
+	})
+	report := testReport(t)
+	report.HtmlFile = "metal-20200327.html"
+
+	//when
+	err := notifier.Notify(context.Background(), report)
+
+	//then
+	require.NoError(t, err)
+	require.Len(t, server.requests, 1)
+	assert.Equal(t, "/message", server.requests[0].URL.Path)
+	assert.Equal(t, "app-token", server.requests[0].Header.Get("X-Gotify-Key"))
+
+	body := server.bodies[0]
+	assert.Equal(t, float64(9), body["priority"])
+	assert.Equal(t, "2 new releases: King Diamond – The Institute (90), Ghost – Impera (60)", body["message"])
+	assert.Equal(t, map[string]any{"client::notification": map[string]any{"click": map[string]any{"url": "https://music.mysite.com/metal-20200327.html"}}}, body["extras"])
+}
+
+func Test_pushSummary(t *testing.T) {
+	release := func(artist string, score int) allmusic.Discography {
+		return allmusic.Discography{Artist: allmusic.Artist{Name: artist}, NewestRelease: allmusic.Album{Title: "Album"}, Score: score}
+	}
+
+	testcases := map[string]struct {
+		Discographies    []allmusic.Discography
+		ExpectedSummary  string
+		ExpectedPriority int
+	}{
+		"No releases": {
+			ExpectedSummary:  "There are no interesting new releases.",
+			ExpectedPriority: 1,
+		},
+		"One release": {
+			Discographies:    []allmusic.Discography{release("Ghost", 60)},
+			ExpectedSummary:  "1 new release: Ghost – Album (60)",
+			ExpectedPriority: 3,
+		},
+		"Many releases": {
+			Discographies:    []allmusic.Discography{release("A", 80), release("B", 70), release("C", 60), release("D", 50), release("E", 40)},
+			ExpectedSummary:  "5 new releases: A – Album (80), B – Album (70), C – Album (60) and 2 more",
+			ExpectedPriority: 4,
+		},
+		"Low scores": {
+			Discographies:    []allmusic.Discography{release("Nickelback", 30)},
+			ExpectedSummary:  "1 new release: Nickelback – Album (30)",
+			ExpectedPriority: 2,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		report := Report{Discographies: testdata.Discographies}
+
+		assert.Equal(t, testdata.ExpectedSummary, pushSummary(report), testcase)
+		assert.Equal(t, testdata.ExpectedPriority, pushPriority(report), testcase)
+	}
+}
+
+func Test_reportUrl(t *testing.T) {
+	assert.Equal(t, "", reportUrl("", Report{HtmlFile: "metal-20200327.html"}), "The reports aren't published")
+	assert.Equal(t, "", reportUrl("https://music.mysite.com", Report{}), "There is no html report")
+	assert.Equal(t, "https://music.mysite.com/rap%20and%20metal-20200327.html", reportUrl("https://music.mysite.com", Report{HtmlFile: "rap and metal-20200327.html"}))
+}
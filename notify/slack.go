@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ynori7/music/allmusic"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/view"
+)
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// SlackNotifier posts the top releases to a Slack incoming webhook using Block Kit
+type SlackNotifier struct {
+	httpClient HttpClient
+	webhook    config.ChatWebhook
+}
+
+func NewSlackNotifier(httpClient HttpClient, webhook config.ChatWebhook) SlackNotifier {
+	return SlackNotifier{
+		httpClient: httpClient,
+		webhook:    webhook,
+	}
+}
+
+func (n SlackNotifier) Name() string {
+	return "slack"
+}
+
+func (n SlackNotifier) Target() string {
+	return host(n.webhook.Url)
+}
+
+func (n SlackNotifier) IsSubscribedTo(profile string) bool {
+	return n.webhook.IsSubscribedTo(profile)
+}
+
+func (n SlackNotifier) Notify(ctx context.Context, report Report) error {
+	return sendJson(ctx, n.httpClient, http.MethodPost, n.webhook.Url, n.buildMessage(report), nil)
+}
+
+type slackMessage struct {
+	Text   string       `json:"text"` //shown in notifications
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type      string      `json:"type"`
+	Text      *slackText  `json:"text,omitempty"`
+	Accessory *slackImage `json:"accessory,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackImage struct {
+	Type     string `json:"type"`
+	ImageUrl string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+func (n SlackNotifier) buildMessage(report Report) slackMessage {
+	message := slackMessage{
+		Text:   title(report),
+		Blocks: []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: title(report)}}},
+	}
+
+	discographies := topDiscographies(report, n.webhook.Top)
+	if len(discographies) == 0 {
+		message.Blocks = append(message.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "There are no interesting new releases."}})
+		return message
+	}
+
+	for _, d := range discographies {
+		block := slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackDescription(d)}}
+		if d.NewestRelease.Image != "" {
+			block.Accessory = &slackImage{Type: "image", ImageUrl: d.NewestRelease.Image, AltText: d.NewestRelease.Title}
+		}
+		message.Blocks = append(message.Blocks, block)
+	}
+	return message
+}
+
+func slackDescription(d allmusic.Discography) string {
+	lines := []string{fmt.Sprintf("*%s – %s*", slackLink(d.Artist.Name, d.Artist.Link), slackLink(d.NewestRelease.Title, d.NewestRelease.Link))}
+	if len(d.Artist.Genres) > 0 {
+		lines = append(lines, slackEscaper.Replace(strings.Join(d.Artist.Genres, ", ")))
+	}
+	lines = append(lines, fmt.Sprintf("%s · Score: %d / 100", view.StarRating(d.NewestRelease.Rating), d.Score))
+	return strings.Join(lines, "\n")
+}
+
+func slackLink(text, url string) string {
+	if url == "" {
+		return slackEscaper.Replace(text)
+	}
+	return "<" + url + "|" + slackEscaper.Replace(text) + ">"
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/config"
+)
+
+func Test_SlackNotifier(t *testing.T) {
+	//given
+	server := newChatServer(t, http.StatusOK)
+	notifier := NewSlackNotifier(http.DefaultClient, config.ChatWebhook{Url: server.URL, Channel: config.Channel{Top: 1}})
+
+	//when
+	err := notifier.Notify(context.Background(), testReport(t))
+
+	//then
+	require.NoError(t, err)
+	require.Len(t, server.bodies, 1)
+	assert.Equal(t, http.MethodPost, server.requests[0].Method)
+
+	body := server.bodies[0]
+	assert.Equal(t, "metal: Newest releases from the week of 2020-03-27", body["text"])
+
+	blocks := body["blocks"].([]any)
+	require.Len(t, blocks, 2, "There should be a header and the top release")
+	assert.Equal(t, "header", blocks[0].(map[string]any)["type"])
+
+	section := blocks[1].(map[string]any)
+	assert.Equal(t, "*<https://www.allmusic.com/artist/king-diamond|King Diamond> – <https://www.allmusic.com/album/the-institute|The Institute>*\nHeavy Metal\n★★★★½ · Score: 90 / 100",
+		section["text"].(map[string]any)["text"])
+	assert.Equal(t, "https://cdn.allmusic.com/the-institute.jpg", section["accessory"].(map[string]any)["image_url"])
+}
+
+func Test_slackLink(t *testing.T) {
+	assert.Equal(t, "Kings &amp; Queens", slackLink("Kings & Queens", ""))
+	assert.Equal(t, "<https://www.allmusic.com/album/x|&lt;3>", slackLink("<3", "https://www.allmusic.com/album/x"))
+}
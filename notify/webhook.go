@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/view"
+)
+
+const signatureHeader = "X-Signature-256"
+
+// WebhookNotifier posts the json report to a webhook. The body is signed with the secret so that the receiver can
+// verify that it's genuine.
+type WebhookNotifier struct {
+	httpClient HttpClient
+	webhook    config.Webhook
+}
+
+func NewWebhookNotifier(httpClient HttpClient, webhook config.Webhook) WebhookNotifier {
+	return WebhookNotifier{
+		httpClient: httpClient,
+		webhook:    webhook,
+	}
+}
+
+func (n WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (n WebhookNotifier) Target() string {
+	return host(n.webhook.Url)
+}
+
+func (n WebhookNotifier) IsSubscribedTo(profile string) bool {
+	return n.webhook.IsSubscribedTo(profile)
+}
+
+func (n WebhookNotifier) Notify(ctx context.Context, report Report) error {
+	body, err := view.NewJsonReport(report.Profile, report.From, report.To, report.Discographies).ExecuteJson()
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	if n.webhook.Secret != "" {
+		header.Set(signatureHeader, Sign(body, n.webhook.Secret))
+	}
+	return send(ctx, n.httpClient, http.MethodPost, n.webhook.Url, body, header)
+}
+
+// Sign returns the signature of the body in the form "sha256=<hex encoded HMAC-SHA256>"
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
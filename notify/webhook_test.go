@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/config"
+	"github.com/ynori7/music/view"
+)
+
+func Test_WebhookNotifier(t *testing.T) {
+	//given
+	attempts := 0
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(signatureHeader)
+	}))
+	defer server.Close()
+
+	conf := config.Config{
+		Notifiers: config.Notifiers{
+			Webhooks: []config.Webhook{{Url: server.URL + "/hooks/music", Secret: "secret"}},
+			Retry:    config.Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond},
+		},
+	}
+	notifiers := NewNotifiers(conf, http.DefaultClient)
+
+	//when
+	deliveries := NotifyAll(context.Background(), notifiers, []Report{testReport(t)}, 0)
+
+	//then
+	require.Len(t, deliveries, 1)
+	assert.NoError(t, deliveries[0].Err)
+	assert.Equal(t, "webhook", deliveries[0].Notifier)
+	assert.Equal(t, server.Listener.Addr().String(), deliveries[0].Target, "The target shouldn't contain the path")
+	assert.Equal(t, 2, attempts, "The failed delivery should be retried")
+
+	assert.Equal(t, Sign(body, "secret"), signature)
+	var report view.JsonReport
+	require.NoError(t, json.Unmarshal(body, &report))
+	assert.Equal(t, "metal", report.Title)
+	assert.Equal(t, "20200327", report.From)
+	assert.Len(t, report.Discographies, 2, "The webhook should receive all releases")
+}
+
+func Test_WebhookNotifier_Unsigned(t *testing.T) {
+	//given
+	server := newChatServer(t, http.StatusOK)
+	notifier := NewWebhookNotifier(http.DefaultClient, config.Webhook{Url: server.URL})
+
+	//when
+	err := notifier.Notify(context.Background(), testReport(t))
+
+	//then
+	require.NoError(t, err)
+	assert.Empty(t, server.requests[0].Header.Get(signatureHeader))
+}
+
+func Test_Sign(t *testing.T) {
+	//the example of the GitHub webhook documentation
+	assert.Equal(t,
+		"sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+		Sign([]byte("Hello, World!"), "It's a Secret to Everybody"),
+	)
+}
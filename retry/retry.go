@@ -0,0 +1,115 @@
+package retry
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ynori7/music/config"
+)
+
+// HttpClient performs the requests which are retried
+type HttpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client retries requests which failed with a transport error, a timeout, a 429 or a 5xx status.
+// The wait between attempts grows exponentially with some random jitter, unless the server tells us how
+// long to wait with a Retry-After header. When the wait wouldn't end before the deadline of the request's
+// context, the last outcome is returned right away.
+type Client struct {
+	httpClient HttpClient
+	conf       config.Retry
+}
+
+func NewClient(httpClient HttpClient, conf config.Retry) Client {
+	return Client{
+		httpClient: httpClient,
+		conf:       conf,
+	}
+}
+
+func (c Client) Do(req *http.Request) (*http.Response, error) {
+	logger := log.WithFields(log.Fields{"Logger": "retry.Client", "Host": req.URL.Host})
+
+	for attempt := 1; ; attempt++ {
+		res, err := c.httpClient.Do(req)
+		if attempt >= c.conf.MaxAttempts || req.Context().Err() != nil || !isRetryable(res, err) {
+			return res, err
+		}
+
+		wait := c.backoff(attempt, res)
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < wait {
+			return res, err //the next attempt would be canceled anyway
+		}
+		if res != nil {
+			io.Copy(io.Discard, res.Body) //drain the body so the connection can be reused
+			res.Body.Close()
+		}
+		logger.WithFields(log.Fields{"attempt": attempt, "wait": wait, "error": err}).Info("Retrying request")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		//the body was consumed by the previous attempt
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func isRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true //transport errors and timeouts of the individual attempt
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// backoff determines how long to wait before the next attempt
+func (c Client) backoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if wait, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			return min(wait, c.conf.MaxBackoff) //a server shouldn't be able to stall the run
+		}
+	}
+
+	wait := c.conf.InitialBackoff << (attempt - 1)
+	if wait > c.conf.MaxBackoff || wait <= 0 {
+		wait = c.conf.MaxBackoff
+	}
+
+	//use a random wait between half and the full backoff so that the workers don't retry in lockstep
+	half := int64(wait / 2)
+	if half <= 0 {
+		return wait
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// parseRetryAfter supports both forms of the header: a number of seconds or an HTTP date
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
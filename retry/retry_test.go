@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hulkhttp "github.com/ynori7/hulksmash/http"
+	"github.com/ynori7/music/config"
+)
+
+func Test_Client(t *testing.T) {
+	testcases := map[string]struct {
+		Statuses         []int
+		ExpectedStatus   int
+		ExpectedRequests int
+	}{
+		"Success": {
+			Statuses:         []int{200},
+			ExpectedStatus:   200,
+			ExpectedRequests: 1,
+		},
+		"Recovers from server errors": {
+			Statuses:         []int{503, 500, 200},
+			ExpectedStatus:   200,
+			ExpectedRequests: 3,
+		},
+		"Recovers from too many requests": {
+			Statuses:         []int{429, 200},
+			ExpectedStatus:   200,
+			ExpectedRequests: 2,
+		},
+		"Gives up after max attempts": {
+			Statuses:         []int{502, 502, 502, 502},
+			ExpectedStatus:   502,
+			ExpectedRequests: 3,
+		},
+		"Doesn't retry client errors": {
+			Statuses:         []int{404, 200},
+			ExpectedStatus:   404,
+			ExpectedRequests: 1,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		reqCount := 0
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(testdata.Statuses[reqCount])
+			reqCount++
+		}))
+
+		retryClient := NewClient(
+			hulkhttp.NewClientV2ForTests(server.Client().Transport),
+			config.Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond},
+		)
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		res, err := retryClient.Do(req)
+
+		require.NoError(t, err, testcase)
+		assert.Equal(t, testdata.ExpectedStatus, res.StatusCode, testcase)
+		assert.Equal(t, testdata.ExpectedRequests, reqCount, testcase)
+		server.Close()
+	}
+}
+
+func Test_Client_ResendsBody(t *testing.T) {
+	//given
+	bodies := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	retryClient := NewClient(
+		server.Client(),
+		config.Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond},
+	)
+
+	//when
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"title":"metal"}`))
+	res, err := retryClient.Do(req)
+
+	//then
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{`{"title":"metal"}`, `{"title":"metal"}`}, bodies)
+}
+
+func Test_Client_Deadline(t *testing.T) {
+	//given
+	reqCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		reqCount++
+		rw.Header().Set("Retry-After", "30")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	retryClient := NewClient(server.Client(), config.Retry{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 30 * time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	//when
+	start := time.Now()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(`{"title":"metal"}`))
+	res, err := retryClient.Do(req)
+
+	//then
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode, "The last response should be returned")
+	assert.Equal(t, 1, reqCount, "The wait doesn't end before the deadline")
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "It shouldn't wait for the deadline")
+}
+
+func Test_Client_backoff(t *testing.T) {
+	retryClient := NewClient(nil, config.Retry{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second})
+
+	for attempt, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		wait := retryClient.backoff(attempt, nil)
+		assert.True(t, wait >= max/2 && wait <= max, "attempt %d: %s should be between %s and %s", attempt, wait, max/2, max)
+	}
+
+	res := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	assert.Equal(t, 3*time.Second, retryClient.backoff(1, res), "The Retry-After header should be honoured")
+
+	res = &http.Response{Header: http.Header{"Retry-After": []string{"120"}}}
+	assert.Equal(t, 5*time.Second, retryClient.backoff(1, res), "The Retry-After header should be limited to the max backoff")
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("7")
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.True(t, wait > 50*time.Second && wait <= time.Minute, "unexpected wait %s", wait)
+
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
@@ -0,0 +1,137 @@
+package rule
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenIn
+	tokenLeftParen
+	tokenRightParen
+	tokenLeftBracket
+	tokenRightBracket
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value string //the unquoted value of string tokens
+	pos   int    //the position in the expression, starting at 1
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of rule"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+var keywords = map[string]tokenKind{
+	"and": tokenAnd,
+	"or":  tokenOr,
+	"not": tokenNot,
+	"in":  tokenIn,
+}
+
+var punctuation = map[rune]tokenKind{
+	'(': tokenLeftParen,
+	')': tokenRightParen,
+	'[': tokenLeftBracket,
+	']': tokenRightBracket,
+	',': tokenComma,
+}
+
+var operators = []string{"==", "!=", "!~", ">=", "<=", "~", ">", "<"} //longer operators first so that ">=" isn't read as ">"
+
+// tokenize splits the expression into tokens
+func tokenize(expr string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case punctuation[r] != tokenEOF:
+			tokens = append(tokens, token{kind: punctuation[r], text: string(r), pos: start + 1})
+			i++
+		case r == '"':
+			value, end, err := readString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[start:end]), value: value, pos: start + 1})
+			i = end
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start + 1})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			text := string(runes[start:i])
+			kind, isKeyword := keywords[strings.ToLower(text)]
+			if !isKeyword {
+				kind = tokenIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start + 1})
+		default:
+			op := readOperator(runes[i:])
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, start+1)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start + 1})
+			i += len([]rune(op))
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+// readString reads a double-quoted string starting at the given position and returns its value
+// and the position after the closing quote. Quotes and backslashes can be escaped with a backslash.
+func readString(runes []rune, start int) (string, int, error) {
+	var value strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				value.WriteRune(runes[i])
+			}
+		case '"':
+			return value.String(), i + 1, nil
+		default:
+			value.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string starting at position %d", start+1)
+}
+
+func readOperator(runes []rune) string {
+	for _, op := range operators {
+		if strings.HasPrefix(string(runes), op) {
+			return op
+		}
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parser is a recursive descent parser for the rule grammar:
+//
+//	or         = and { "or" and }
+//	and        = not { "and" not }
+//	not        = "not" not | primary
+//	primary    = "(" or ")" | comparison
+//	comparison = field operator (string | number) | field "in" (list name | "[" string { "," string } "]")
+type parser struct {
+	tokens []token
+	pos    int
+	fields Fields
+	lists  map[string][]string
+}
+
+func (p *parser) parse() (node, error) {
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, p.errorf(next, "unexpected %s", next)
+	}
+	return root, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), t.pos)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLeftParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, p.errorf(closing, "expected \")\" but found %s", closing)
+		}
+		return inner, nil
+	case tokenIdent:
+		return p.parseComparison(t)
+	default:
+		return nil, p.errorf(t, "expected a field or \"(\" but found %s", t)
+	}
+}
+
+func (p *parser) parseComparison(field token) (node, error) {
+	fieldType, ok := p.fields[field.text]
+	if !ok {
+		return nil, p.errorf(field, "unknown field %q (valid fields are %s)", field.text, p.fields.names())
+	}
+
+	op := p.next()
+	switch {
+	case op.kind == tokenIn:
+		if fieldType != stringField {
+			return nil, p.errorf(op, "\"in\" can only be used with text fields but %q is a number", field.text)
+		}
+		return p.parseList(field.text)
+	case op.kind != tokenOperator:
+		return nil, p.errorf(op, "expected an operator after %q but found %s", field.text, op)
+	}
+
+	value := p.next()
+	switch fieldType {
+	case stringField:
+		if op.text != "==" && op.text != "!=" && op.text != "~" && op.text != "!~" {
+			return nil, p.errorf(op, "operator %q can't be used with text field %q", op.text, field.text)
+		}
+		if value.kind != tokenString {
+			return nil, p.errorf(value, "expected a quoted text after %q but found %s", op.text, value)
+		}
+		return stringComparison{field: field.text, op: op.text, value: value.value}, nil
+	default:
+		if op.text == "~" || op.text == "!~" {
+			return nil, p.errorf(op, "operator %q can't be used with number field %q", op.text, field.text)
+		}
+		if value.kind != tokenNumber {
+			return nil, p.errorf(value, "expected a number after %q but found %s", op.text, value)
+		}
+		number, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, p.errorf(value, "invalid number %s", value)
+		}
+		return numberComparison{field: field.text, op: op.text, value: number}, nil
+	}
+}
+
+func (p *parser) parseList(field string) (node, error) {
+	n := inList{field: field, values: make(map[string]bool)}
+
+	t := p.next()
+	switch t.kind {
+	case tokenIdent:
+		list, ok := p.lists[t.text]
+		if !ok {
+			return nil, p.errorf(t, "unknown list %q", t.text)
+		}
+		for _, v := range list {
+			n.values[v] = true
+		}
+		return n, nil
+	case tokenLeftBracket:
+		for {
+			value := p.next()
+			if value.kind != tokenString {
+				return nil, p.errorf(value, "expected a quoted text in the list but found %s", value)
+			}
+			n.values[value.value] = true
+
+			separator := p.next()
+			if separator.kind == tokenRightBracket {
+				return n, nil
+			}
+			if separator.kind != tokenComma {
+				return nil, p.errorf(separator, "expected \",\" or \"]\" but found %s", separator)
+			}
+		}
+	default:
+		return nil, p.errorf(t, "expected a list name or \"[\" after \"in\" but found %s", t)
+	}
+}
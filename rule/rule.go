@@ -0,0 +1,194 @@
+package rule
+
+import (
+	"sort"
+	"strings"
+)
+
+type fieldType int
+
+const (
+	stringField fieldType = iota
+	numberField
+)
+
+// Fields are the values which can be used in a rule. String fields can have multiple values (e.g. an artist
+// has several genres), in which case a comparison matches when any of the values matches.
+type Fields map[string]fieldType
+
+var (
+	// NewReleaseFields are available before the discography of the artist has been fetched
+	NewReleaseFields = Fields{
+		"artist":     stringField,
+		"link":       stringField,
+		"album":      stringField,
+		"main_genre": stringField, //the genres of the new release
+	}
+
+	// DiscographyFields are available once the discography of the artist has been fetched
+	DiscographyFields = Fields{
+		"artist":         stringField,
+		"link":           stringField,
+		"album":          stringField,
+		"genre":          stringField, //the styles of the artist
+		"main_genre":     stringField,
+		"best_rating":    numberField,
+		"average_rating": numberField,
+		"rated_albums":   numberField,
+		"newest_rating":  numberField,
+		"score":          numberField,
+	}
+)
+
+func (f Fields) names() string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Env holds the values a rule is evaluated against. Fields which aren't set never match a comparison.
+type Env struct {
+	Strings map[string][]string
+	Numbers map[string]float64
+}
+
+func NewEnv() Env {
+	return Env{
+		Strings: make(map[string][]string),
+		Numbers: make(map[string]float64),
+	}
+}
+
+// Rule is a compiled filter expression such as:
+//
+//	genre ~ "Metal" and not genre == "Nu Metal" and best_rating >= 8 or artist in watchlist
+//
+// Strings can be compared with == and != or checked for a substring with ~ and !~, numbers can be compared with
+// ==, !=, >, >=, < and <=, and "in" checks whether a value is contained in a named list or a literal list like
+// ["Rap", "Metal"]. Conditions can be combined with and, or, not and parentheses.
+type Rule struct {
+	source string
+	root   node
+}
+
+// Compile parses the expression. Only the given fields may be used and named lists which are referenced with "in"
+// must be contained in lists.
+func Compile(expr string, fields Fields, lists map[string][]string) (*Rule, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens, fields: fields, lists: lists}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rule{source: expr, root: root}, nil
+}
+
+// Match evaluates the rule against the given values
+func (r *Rule) Match(env Env) bool {
+	return r.root.eval(env)
+}
+
+func (r *Rule) String() string {
+	return r.source
+}
+
+type node interface {
+	eval(env Env) bool
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) eval(env Env) bool {
+	return n.left.eval(env) && n.right.eval(env)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) eval(env Env) bool {
+	return n.left.eval(env) || n.right.eval(env)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(env Env) bool {
+	return !n.operand.eval(env)
+}
+
+type stringComparison struct {
+	field string
+	op    string
+	value string
+}
+
+func (n stringComparison) eval(env Env) bool {
+	matches := false
+	for _, v := range env.Strings[n.field] {
+		if (n.op == "~" || n.op == "!~") && strings.Contains(v, n.value) {
+			matches = true
+		} else if (n.op == "==" || n.op == "!=") && v == n.value {
+			matches = true
+		}
+	}
+
+	if n.op == "!=" || n.op == "!~" {
+		return !matches //none of the values may match
+	}
+	return matches
+}
+
+type numberComparison struct {
+	field string
+	op    string
+	value float64
+}
+
+func (n numberComparison) eval(env Env) bool {
+	v, ok := env.Numbers[n.field]
+	if !ok {
+		return false
+	}
+
+	switch n.op {
+	case "==":
+		return v == n.value
+	case "!=":
+		return v != n.value
+	case ">":
+		return v > n.value
+	case ">=":
+		return v >= n.value
+	case "<":
+		return v < n.value
+	case "<=":
+		return v <= n.value
+	}
+	return false
+}
+
+type inList struct {
+	field  string
+	values map[string]bool
+}
+
+func (n inList) eval(env Env) bool {
+	for _, v := range env.Strings[n.field] {
+		if n.values[v] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package rule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLists = map[string][]string{
+	"watchlist": {"King Diamond", "Mercyful Fate"},
+}
+
+func testEnv(artist string, genres []string, bestRating float64) Env {
+	env := NewEnv()
+	env.Strings["artist"] = []string{artist}
+	env.Strings["genre"] = genres
+	env.Numbers["best_rating"] = bestRating
+	return env
+}
+
+func Test_Match(t *testing.T) {
+	testcases := map[string]struct {
+		Rule     string
+		Env      Env
+		Expected bool
+	}{
+		"Fuzzy genre match": {
+			Rule:     `genre ~ "Metal"`,
+			Env:      testEnv("Manowar", []string{"Heavy Metal"}, 7),
+			Expected: true,
+		},
+		"Exact genre mismatch": {
+			Rule:     `genre == "Metal"`,
+			Env:      testEnv("Manowar", []string{"Heavy Metal"}, 7),
+			Expected: false,
+		},
+		"Excluded genre": {
+			Rule:     `genre ~ "Metal" and not genre == "Nu Metal"`,
+			Env:      testEnv("Limp Bizkit", []string{"Rap-Metal", "Nu Metal"}, 6),
+			Expected: false,
+		},
+		"Not equal means none of the values": {
+			Rule:     `genre != "Nu Metal"`,
+			Env:      testEnv("Limp Bizkit", []string{"Rap-Metal", "Nu Metal"}, 6),
+			Expected: false,
+		},
+		"Not contains": {
+			Rule:     `genre !~ "Pop"`,
+			Env:      testEnv("Manowar", []string{"Heavy Metal"}, 7),
+			Expected: true,
+		},
+		"Rating too low": {
+			Rule:     `genre ~ "Metal" and best_rating >= 8`,
+			Env:      testEnv("Manowar", []string{"Heavy Metal"}, 7),
+			Expected: false,
+		},
+		"Watchlist overrides": {
+			Rule:     `genre ~ "Metal" and not genre == "Nu Metal" and best_rating >= 8 or artist in watchlist`,
+			Env:      testEnv("King Diamond", []string{"Pop"}, 2),
+			Expected: true,
+		},
+		"Literal list": {
+			Rule:     `genre in ["Grunge", "Heavy Metal"]`,
+			Env:      testEnv("Manowar", []string{"Heavy Metal"}, 7),
+			Expected: true,
+		},
+		"Parentheses": {
+			Rule:     `(artist in watchlist or best_rating > 8) and genre ~ "Metal"`,
+			Env:      testEnv("King Diamond", []string{"Pop"}, 9),
+			Expected: false,
+		},
+		"Double negation": {
+			Rule:     `not not best_rating < 8.5`,
+			Env:      testEnv("Manowar", []string{"Heavy Metal"}, 8),
+			Expected: true,
+		},
+		"Unset number": {
+			Rule:     `score >= 0`,
+			Env:      testEnv("Manowar", []string{"Heavy Metal"}, 8),
+			Expected: false,
+		},
+		"Escaped quotes and keywords in upper case": {
+			Rule:     `artist == "Guns \"N\" Roses" OR artist == "Mot\\orhead"`,
+			Env:      testEnv(`Mot\orhead`, nil, 8),
+			Expected: true,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		r, err := Compile(testdata.Rule, DiscographyFields, testLists)
+		require.NoError(t, err, testcase)
+		assert.Equal(t, testdata.Expected, r.Match(testdata.Env), testcase)
+	}
+}
+
+func Test_Compile_Errors(t *testing.T) {
+	testcases := map[string]struct {
+		Rule          string
+		ExpectedError string
+	}{
+		"Unknown field": {
+			Rule:          `genres ~ "Metal"`,
+			ExpectedError: `unknown field "genres"`,
+		},
+		"Unknown list": {
+			Rule:          `artist in favourites`,
+			ExpectedError: `unknown list "favourites" at position 11`,
+		},
+		"Missing value": {
+			Rule:          `genre ~ "Metal" and best_rating >=`,
+			ExpectedError: `expected a number after ">=" but found end of rule at position 35`,
+		},
+		"Number operator on text": {
+			Rule:          `genre >= "Metal"`,
+			ExpectedError: `operator ">=" can't be used with text field "genre" at position 7`,
+		},
+		"Text operator on number": {
+			Rule:          `best_rating ~ "8"`,
+			ExpectedError: `operator "~" can't be used with number field "best_rating"`,
+		},
+		"Unquoted text": {
+			Rule:          `genre == Metal`,
+			ExpectedError: `expected a quoted text after "==" but found "Metal"`,
+		},
+		"Unbalanced parentheses": {
+			Rule:          `(genre ~ "Metal"`,
+			ExpectedError: `expected ")" but found end of rule`,
+		},
+		"Trailing tokens": {
+			Rule:          `genre ~ "Metal" "Rap"`,
+			ExpectedError: `unexpected "\"Rap\"" at position 17`,
+		},
+		"Unterminated string": {
+			Rule:          `genre ~ "Metal`,
+			ExpectedError: `unterminated string starting at position 9`,
+		},
+		"Unexpected character": {
+			Rule:          `genre = "Metal"`,
+			ExpectedError: `unexpected character '=' at position 7`,
+		},
+		"Empty rule": {
+			Rule:          ``,
+			ExpectedError: `expected a field or "(" but found end of rule at position 1`,
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		_, err := Compile(testdata.Rule, DiscographyFields, testLists)
+		require.Error(t, err, testcase)
+		assert.Contains(t, err.Error(), testdata.ExpectedError, testcase)
+	}
+}
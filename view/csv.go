@@ -0,0 +1,51 @@
+package view
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+
+	"github.com/ynori7/music/allmusic"
+)
+
+var csvHeader = []string{"rank", "artist", "album", "genres", "rating", "score", "album_link", "artist_link"}
+
+// CsvReport is a flat table of the releases with one row per release, e.g. for spreadsheets
+type CsvReport struct {
+	Discographies []allmusic.Discography
+}
+
+func NewCsvReport(discographies []allmusic.Discography) CsvReport {
+	return CsvReport{
+		Discographies: discographies,
+	}
+}
+
+// ExecuteCsv renders the table. Genres are separated by semicolons and ratings are out of 10, with 0 meaning not rated.
+func (c CsvReport) ExecuteCsv() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for i, d := range c.Discographies {
+		err := w.Write([]string{
+			strconv.Itoa(i + 1),
+			d.Artist.Name,
+			d.NewestRelease.Title,
+			strings.Join(d.Artist.Genres, "; "),
+			strconv.Itoa(d.NewestRelease.Rating),
+			strconv.Itoa(d.Score),
+			d.NewestRelease.Link,
+			d.Artist.Link,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
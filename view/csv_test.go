@@ -0,0 +1,43 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+)
+
+func Test_ExecuteCsv(t *testing.T) {
+	//given
+	discographies := []allmusic.Discography{
+		{
+			Artist: allmusic.Artist{
+				Name:   "King Diamond",
+				Genres: []string{"Heavy Metal", "Black Metal"},
+				Link:   "https://www.allmusic.com/artist/king-diamond-mn0000770007",
+			},
+			NewestRelease: allmusic.Album{
+				Title:  "Give Me Your Soul... Please",
+				Link:   "https://www.allmusic.com/album/give-me-your-soul-please-mw0000733393",
+				Rating: 9,
+			},
+			Score: 90,
+		},
+		{
+			Artist:        allmusic.Artist{Name: "Crosby, Stills & Nash"},
+			NewestRelease: allmusic.Album{Title: "CSN"},
+			Score:         60,
+		},
+	}
+
+	//when
+	out, err := NewCsvReport(discographies).ExecuteCsv()
+
+	//then
+	require.NoError(t, err)
+	assert.Equal(t, `rank,artist,album,genres,rating,score,album_link,artist_link
+1,King Diamond,Give Me Your Soul... Please,Heavy Metal; Black Metal,9,90,https://www.allmusic.com/album/give-me-your-soul-please-mw0000733393,https://www.allmusic.com/artist/king-diamond-mn0000770007
+2,"Crosby, Stills & Nash",CSN,,0,60,,
+`, string(out))
+}
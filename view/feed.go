@@ -0,0 +1,221 @@
+package view
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ynori7/music/allmusic"
+)
+
+const newReleasesPage = "https://www.allmusic.com/newreleases"
+
+// FeedEntry is a release in the feed along with when it was reported
+type FeedEntry struct {
+	Week        allmusic.ReleaseWeek
+	Published   time.Time
+	Discography allmusic.Discography
+}
+
+// Feed renders the releases as an Atom or RSS 2.0 feed. The entries are expected to be sorted, newest first.
+type Feed struct {
+	Title   string
+	Url     string //optional, where the feed is published
+	Entries []FeedEntry
+}
+
+func NewFeed(title, url string, entries []FeedEntry) Feed {
+	return Feed{
+		Title:   title,
+		Url:     url,
+		Entries: entries,
+	}
+}
+
+// ExecuteAtom renders the Atom feed
+func (f Feed) ExecuteAtom() ([]byte, error) {
+	feed := atomFeed{
+		ID:      f.id(),
+		Title:   f.Title,
+		Updated: f.updated().Format(time.RFC3339),
+		Author:  &atomAuthor{Name: "Allmusic"},
+		Links:   []atomLink{{Href: f.link()}},
+	}
+	if f.Url != "" {
+		feed.Links = append(feed.Links, atomLink{Href: f.Url, Rel: "self"})
+	}
+
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:        entryID(e.Discography),
+			Title:     entryTitle(e.Discography),
+			Updated:   e.Published.Format(time.RFC3339),
+			Published: e.Published.Format(time.RFC3339),
+			Author:    &atomAuthor{Name: e.Discography.Artist.Name},
+			Summary:   entrySummary(e),
+		}
+		if link := e.Discography.NewestRelease.Link; link != "" {
+			entry.Links = []atomLink{{Href: link}}
+		}
+		for _, genre := range e.Discography.Artist.Genres {
+			entry.Categories = append(entry.Categories, atomCategory{Term: genre})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalFeed(feed)
+}
+
+// ExecuteRss renders the RSS 2.0 feed
+func (f Feed) ExecuteRss() ([]byte, error) {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.link(),
+		Description:   fmt.Sprintf("Interesting new releases for %s", f.Title),
+		LastBuildDate: f.updated().Format(time.RFC1123Z),
+	}
+
+	for _, e := range f.Entries {
+		link := e.Discography.NewestRelease.Link
+		item := rssItem{
+			Title:       entryTitle(e.Discography),
+			Link:        link,
+			Guid:        rssGuid{Value: entryID(e.Discography), IsPermaLink: link != ""},
+			PubDate:     e.Published.Format(time.RFC1123Z),
+			Description: entrySummary(e),
+			Categories:  e.Discography.Artist.Genres,
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	return marshalFeed(rss{Version: "2.0", Channel: channel})
+}
+
+// entryID identifies the release in a feed. The album link is used when available, so the entries stay the same
+// across runs and readers don't show them twice.
+func entryID(d allmusic.Discography) string {
+	if d.NewestRelease.Link != "" {
+		return d.NewestRelease.Link
+	}
+	hash := sha1.Sum([]byte(d.Artist.Link + "|" + d.NewestRelease.Title))
+	return "urn:sha1:" + hex.EncodeToString(hash[:])
+}
+
+func (f Feed) id() string {
+	if f.Url != "" {
+		return f.Url
+	}
+	return "urn:music:feed:" + url.PathEscape(f.Title)
+}
+
+func (f Feed) link() string {
+	if f.Url != "" {
+		return f.Url
+	}
+	return newReleasesPage
+}
+
+// updated is when the newest entry was published
+func (f Feed) updated() time.Time {
+	var updated time.Time
+	for _, e := range f.Entries {
+		if e.Published.After(updated) {
+			updated = e.Published
+		}
+	}
+	if updated.IsZero() {
+		return time.Now()
+	}
+	return updated
+}
+
+func entryTitle(d allmusic.Discography) string {
+	return d.Artist.Name + " - " + d.NewestRelease.Title
+}
+
+func entrySummary(e FeedEntry) string {
+	lines := make([]string, 0)
+	if len(e.Discography.Artist.Genres) > 0 {
+		lines = append(lines, "Genres: "+strings.Join(e.Discography.Artist.Genres, ", "))
+	}
+	lines = append(lines,
+		"Rating: "+textRating(e.Discography.NewestRelease.Rating),
+		fmt.Sprintf("Score: %d / 100", e.Discography.Score),
+		"Release week: "+e.Week.Format("January 2, 2006"),
+	)
+	return strings.Join(lines, "\n")
+}
+
+func marshalFeed(feed any) ([]byte, error) {
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Author     *atomAuthor    `xml:"author"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Guid        rssGuid  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGuid struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
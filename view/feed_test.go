@@ -0,0 +1,85 @@
+package view
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+)
+
+func testFeed(t *testing.T) Feed {
+	week, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err)
+
+	return NewFeed("metal", "", []FeedEntry{
+		{
+			Week:      week,
+			Published: time.Date(2020, 3, 27, 14, 0, 0, 0, time.UTC),
+			Discography: allmusic.Discography{
+				Artist:        allmusic.Artist{Name: "King Diamond", Genres: []string{"Heavy Metal", "Black Metal"}},
+				NewestRelease: allmusic.Album{Title: "The Institute", Link: "https://www.allmusic.com/album/the-institute", Rating: 9},
+				Score:         90,
+			},
+		},
+		{
+			Week:      week,
+			Published: time.Date(2020, 3, 27, 14, 0, 0, 0, time.UTC),
+			Discography: allmusic.Discography{
+				Artist:        allmusic.Artist{Name: "Ghost", Link: "https://www.allmusic.com/artist/ghost"},
+				NewestRelease: allmusic.Album{Title: "Impera"},
+				Score:         60,
+			},
+		},
+	})
+}
+
+func Test_ExecuteAtom(t *testing.T) {
+	//when
+	out, err := testFeed(t).ExecuteAtom()
+
+	//then
+	require.NoError(t, err)
+
+	var feed atomFeed
+	require.NoError(t, xml.Unmarshal(out, &feed), "The feed should be valid xml")
+	assert.Equal(t, "urn:music:feed:metal", feed.ID)
+	assert.Equal(t, "2020-03-27T14:00:00Z", feed.Updated)
+	require.Len(t, feed.Entries, 2)
+	assert.Equal(t, "https://www.allmusic.com/album/the-institute", feed.Entries[0].ID)
+	assert.Equal(t, "King Diamond - The Institute", feed.Entries[0].Title)
+	assert.Equal(t, "Genres: Heavy Metal, Black Metal\nRating: 4.5 / 5 stars\nScore: 90 / 100\nRelease week: March 27, 2020", feed.Entries[0].Summary)
+	assert.Len(t, feed.Entries[0].Categories, 2)
+	assert.Regexp(t, "^urn:sha1:[0-9a-f]{40}$", feed.Entries[1].ID, "Albums without a link should get a hashed id")
+}
+
+func Test_ExecuteRss(t *testing.T) {
+	//when
+	out, err := testFeed(t).ExecuteRss()
+
+	//then
+	require.NoError(t, err)
+
+	var feed rss
+	require.NoError(t, xml.Unmarshal(out, &feed), "The feed should be valid xml")
+	assert.Equal(t, "2.0", feed.Version)
+	assert.Equal(t, newReleasesPage, feed.Channel.Link)
+	require.Len(t, feed.Channel.Items, 2)
+	assert.Equal(t, rssGuid{Value: "https://www.allmusic.com/album/the-institute", IsPermaLink: true}, feed.Channel.Items[0].Guid)
+	assert.False(t, feed.Channel.Items[1].Guid.IsPermaLink)
+}
+
+func Test_entryID(t *testing.T) {
+	discography := allmusic.Discography{
+		Artist:        allmusic.Artist{Link: "https://www.allmusic.com/artist/ghost"},
+		NewestRelease: allmusic.Album{Title: "Impera"},
+	}
+
+	assert.Equal(t, entryID(discography), entryID(discography), "The id should be stable")
+
+	other := discography
+	other.NewestRelease.Title = "Prequelle"
+	assert.NotEqual(t, entryID(discography), entryID(other))
+}
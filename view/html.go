@@ -11,39 +11,58 @@ import (
 
 type HtmlTemplate struct {
 	Discographies []allmusic.Discography
+
+	inlineImages map[string]string //the content ids of the images which are attached to the email, by url
+	template     *template.Template
 }
 
 func NewHtmlTemplate(discographies []allmusic.Discography) HtmlTemplate {
 	return HtmlTemplate{
 		Discographies: discographies,
+		template:      defaultHtmlTemplate,
+	}
+}
+
+// WithTemplates uses the custom HTML template if there is one
+func (h HtmlTemplate) WithTemplates(templates Templates) HtmlTemplate {
+	if templates.html != nil {
+		h.template = templates.html
 	}
+	return h
+}
+
+// WithInlineImages references the attached images by their content ids instead of their urls
+func (h HtmlTemplate) WithInlineImages(contentIDs map[string]string) HtmlTemplate {
+	h.inlineImages = contentIDs
+	return h
+}
+
+// ImageUrls returns the urls of the cover and rating images which are shown in the report
+func (h HtmlTemplate) ImageUrls() []string {
+	seen := make(map[string]bool)
+	urls := make([]string, 0)
+	for _, d := range h.Discographies {
+		for _, url := range []string{coverImageUrl(d.NewestRelease.Image), ratingImageUrl(d.NewestRelease.Rating)} {
+			if !seen[url] {
+				seen[url] = true
+				urls = append(urls, url)
+			}
+		}
+	}
+	return urls
 }
 
 func (h HtmlTemplate) ExecuteHtmlTemplate() (string, error) {
-	t := template.Must(template.New("html").
-		Funcs(template.FuncMap{
-			"mod": func(i, j int) bool { return i%j == 0 },
-			"allmusicRating": func(r int) string {
-				switch r {
-				case 0, 1:
-					return "https://fastly-gce.allmusic.com/images/newsletter/allmusic-0.png"
-				default:
-					return fmt.Sprintf("https://fastly-gce.allmusic.com/images/newsletter/allmusic-%d.png", r-1)
-				}
-			},
-			"coverImage": func(s string) string {
-				if len(s) == 0 {
-					return "https://fastly-gce.allmusic.com/images/no_image/album_300x300.png"
-				}
-				return s
-			},
-		}).
-		Parse(htmlTemplate))
+	// the funcs refer to the inline images of this report, so they're bound to a copy of the shared template
+	t, err := h.template.Clone()
+	if err != nil {
+		return "", err
+	}
 
 	var b bytes.Buffer
 	w := bufio.NewWriter(&b)
 
-	err := t.Execute(w, h)
+	err = t.Funcs(h.funcs()).Execute(w, h)
 	if err != nil {
 		return "", err
 	}
@@ -52,6 +71,39 @@ func (h HtmlTemplate) ExecuteHtmlTemplate() (string, error) {
 	return b.String(), nil
 }
 
+// funcs are the helpers which are available in the HTML templates
+func (h HtmlTemplate) funcs() template.FuncMap {
+	return template.FuncMap{
+		"mod":            func(i, j int) bool { return i%j == 0 },
+		"allmusicRating": func(r int) any { return h.imageSrc(ratingImageUrl(r)) },
+		"coverImage":     func(s string) any { return h.imageSrc(coverImageUrl(s)) },
+	}
+}
+
+// imageSrc returns the cid url of attached images. They're marked as safe because the template would reject the scheme.
+func (h HtmlTemplate) imageSrc(url string) any {
+	if contentID, ok := h.inlineImages[url]; ok {
+		return template.URL("cid:" + contentID)
+	}
+	return url
+}
+
+func ratingImageUrl(r int) string {
+	switch r {
+	case 0, 1:
+		return "https://fastly-gce.allmusic.com/images/newsletter/allmusic-0.png"
+	default:
+		return fmt.Sprintf("https://fastly-gce.allmusic.com/images/newsletter/allmusic-%d.png", r-1)
+	}
+}
+
+func coverImageUrl(s string) string {
+	if len(s) == 0 {
+		return "https://fastly-gce.allmusic.com/images/no_image/album_300x300.png"
+	}
+	return s
+}
+
 const htmlTemplate = `<html>
 <head>
 	<style>
@@ -76,6 +128,12 @@ const htmlTemplate = `<html>
 	.coverImage {
 		width:192px;
 	}
+	.watchlisted .coverImage {
+		border:3px solid #d4a017;box-sizing:border-box;
+	}
+	p.watchlist {
+		font-size:8pt;font-weight:bold;color:#d4a017;margin:5px 0 0;
+	}
 	</style>
 </head>
 <body>
@@ -89,10 +147,11 @@ const htmlTemplate = `<html>
                 <tbody>
 				{{range $i, $val := .Discographies}}
 					{{ if eq $i 0 }}<tr>{{ else if mod $i 3 }}</tr><tr>{{ else }}<td width="2%" align="center" valign="top">&nbsp;</td>{{ end }}
-					<td width="32%" align="left" valign="top">
+					<td width="32%" align="left" valign="top"{{ if $val.Watchlisted }} class="watchlisted"{{ end }}>
                     	<a href="{{ $val.NewestRelease.Link }}">
                         	<img class="coverImage" src="{{ coverImage $val.NewestRelease.Image }}"><br>
                         </a>
+						{{ if $val.Watchlisted }}<p class="watchlist">&#9733; Watchlist</p>{{ end }}
                         <h3 class="artist">
                         	<a href="{{ $val.Artist.Link }}">{{ $val.Artist.Name }}</a>
                         </h3>
@@ -105,7 +164,7 @@ const htmlTemplate = `<html>
 							</span>
                         </h4>
 						<img src="{{ allmusicRating $val.NewestRelease.Rating }}" width="auto" height="auto" alt="star rating"><br>
-                        <p class="score">Score: {{ $val.Score }} / 10</p>
+                        <p class="score">Score: {{ $val.Score }} / 100</p>
                         </td>
 				{{ end }}
               </tr>
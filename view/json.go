@@ -0,0 +1,31 @@
+package view
+
+import (
+	"encoding/json"
+
+	"github.com/ynori7/music/allmusic"
+)
+
+// JsonReport is the machine-readable version of a report including all albums, scores and matched rules
+type JsonReport struct {
+	Title         string                 `json:"title"`
+	From          string                 `json:"from"` //the first release week in the format yyyyMMdd
+	To            string                 `json:"to"`   //the last release week in the format yyyyMMdd
+	Discographies []allmusic.Discography `json:"discographies"`
+}
+
+func NewJsonReport(title string, from, to allmusic.ReleaseWeek, discographies []allmusic.Discography) JsonReport {
+	if discographies == nil {
+		discographies = make([]allmusic.Discography, 0) //an empty report should be an empty list rather than null
+	}
+	return JsonReport{
+		Title:         title,
+		From:          from.String(),
+		To:            to.String(),
+		Discographies: discographies,
+	}
+}
+
+func (r JsonReport) ExecuteJson() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
@@ -0,0 +1,46 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+)
+
+func Test_ExecuteJson(t *testing.T) {
+	//given
+	from, err := allmusic.ParseReleaseWeek("20200320")
+	require.NoError(t, err)
+	to, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err)
+
+	discographies := []allmusic.Discography{{
+		Artist:        allmusic.Artist{Name: "Ghost", Genres: []string{"Heavy Metal"}},
+		NewestRelease: allmusic.Album{Title: "Impera", Rating: 8},
+		Score:         60,
+		MatchedRules:  []string{"sub_genre: Heavy Metal"},
+	}}
+
+	//when
+	out, err := NewJsonReport("metal", from, to, discographies).ExecuteJson()
+
+	//then
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"title": "metal"`)
+	assert.Contains(t, string(out), `"from": "20200320"`)
+	assert.Contains(t, string(out), `"to": "20200327"`)
+	assert.Contains(t, string(out), `"average_rating": 0`)
+	assert.Contains(t, string(out), `"newest_release": {
+        "title": "Impera",`)
+	assert.Contains(t, string(out), `"matched_rules": [
+        "sub_genre: Heavy Metal"
+      ]`)
+}
+
+func Test_ExecuteJson_Empty(t *testing.T) {
+	out, err := NewJsonReport("metal", allmusic.ReleaseWeek{}, allmusic.ReleaseWeek{}, nil).ExecuteJson()
+
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"discographies": []`)
+}
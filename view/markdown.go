@@ -0,0 +1,113 @@
+package view
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/ynori7/music/allmusic"
+)
+
+var (
+	markdownTableTemplate = template.Must(template.New("markdownTable").Funcs(markdownFuncs).Parse(markdownTable))
+	markdownListTemplate  = template.Must(template.New("markdownList").Funcs(markdownFuncs).Parse(markdownList))
+)
+
+// MarkdownTemplate renders the report as a Markdown table or list, e.g. for wikis or as the body of chat messages
+type MarkdownTemplate struct {
+	Discographies []allmusic.Discography
+
+	template   *template.Template
+	hideCovers bool
+}
+
+func NewMarkdownTemplate(discographies []allmusic.Discography) MarkdownTemplate {
+	return MarkdownTemplate{
+		Discographies: discographies,
+		template:      markdownTableTemplate,
+	}
+}
+
+// AsList renders a numbered list instead of a table, which reads better where tables aren't supported
+func (m MarkdownTemplate) AsList() MarkdownTemplate {
+	m.template = markdownListTemplate
+	return m
+}
+
+// WithoutCovers leaves out the cover thumbnails, e.g. for plain text chat messages
+func (m MarkdownTemplate) WithoutCovers() MarkdownTemplate {
+	m.hideCovers = true
+	return m
+}
+
+// Covers tells the templates whether to show the cover thumbnails
+func (m MarkdownTemplate) Covers() bool {
+	return !m.hideCovers
+}
+
+// WithLimit only renders the first releases, e.g. to keep chat messages short
+func (m MarkdownTemplate) WithLimit(limit int) MarkdownTemplate {
+	if limit > 0 && limit < len(m.Discographies) {
+		m.Discographies = m.Discographies[:limit]
+	}
+	return m
+}
+
+func (m MarkdownTemplate) ExecuteMarkdownTemplate() (string, error) {
+	var b bytes.Buffer
+	if err := m.template.Execute(&b, m); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+var markdownFuncs = template.FuncMap{
+	"inc":        func(i int) int { return i + 1 },
+	"coverImage": coverImageUrl,
+	"escape":     escapeMarkdown,
+	"link":       markdownLink,
+	"stars":      StarRating,
+	"genres":     func(genres []string) string { return escapeMarkdown(strings.Join(genres, ", ")) },
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "|", `\|`, "#", `\#`,
+)
+
+// escapeMarkdown escapes the characters which would otherwise be formatting, e.g. in artist names like "*NSYNC"
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownLink links the text unless there's no url
+func markdownLink(text, url string) string {
+	if url == "" {
+		return escapeMarkdown(text)
+	}
+	return "[" + escapeMarkdown(text) + "](" + url + ")"
+}
+
+// StarRating converts the rating from 0 to 10 to stars like ★★★½☆
+func StarRating(r int) string {
+	if r == 0 {
+		return "not rated yet"
+	}
+	stars := strings.Repeat("★", r/2)
+	if r%2 == 1 {
+		stars += "½"
+	}
+	return stars + strings.Repeat("☆", (10-r)/2)
+}
+
+const markdownTable = `{{ if .Discographies }}| # |{{ if .Covers }} Cover |{{ end }} Artist | Album | Genres | Rating | Score |
+|---|{{ if .Covers }}---|{{ end }}---|---|---|---|---|
+{{ range $i, $val := .Discographies }}| {{ inc $i }} |{{ if $.Covers }} ![cover]({{ coverImage $val.NewestRelease.Image }}) |{{ end }} {{ link $val.Artist.Name $val.Artist.Link }}{{ if $val.Watchlisted }} (watchlist){{ end }} | {{ link $val.NewestRelease.Title $val.NewestRelease.Link }} | {{ genres $val.Artist.Genres }} | {{ stars $val.NewestRelease.Rating }} | {{ $val.Score }} |
+{{ end }}{{ else }}There are no interesting new releases.
+{{ end }}`
+
+const markdownList = `{{ range $i, $val := .Discographies }}{{ inc $i }}. {{ if $.Covers }}![cover]({{ coverImage $val.NewestRelease.Image }}) {{ end }}**{{ link $val.Artist.Name $val.Artist.Link }} – {{ link $val.NewestRelease.Title $val.NewestRelease.Link }}**{{ if $val.Watchlisted }} (watchlist){{ end }}
+{{ if $val.Artist.Genres }}   - Genres: {{ genres $val.Artist.Genres }}
+{{ end }}   - Rating: {{ stars $val.NewestRelease.Rating }}
+   - Score: {{ $val.Score }} / 100
+{{ else }}There are no interesting new releases.
+{{ end }}`
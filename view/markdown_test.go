@@ -0,0 +1,85 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+)
+
+var markdownDiscographies = []allmusic.Discography{
+	{
+		Artist: allmusic.Artist{
+			Name:   "King Diamond",
+			Genres: []string{"Heavy Metal", "Black Metal"},
+			Link:   "https://www.allmusic.com/artist/king-diamond-mn0000770007",
+		},
+		NewestRelease: allmusic.Album{
+			Title:  "Give Me Your Soul... Please",
+			Link:   "https://www.allmusic.com/album/give-me-your-soul-please-mw0000733393",
+			Image:  "https://cdn.allmusic.com/cover.jpg",
+			Rating: 9,
+		},
+		Score:       90,
+		Watchlisted: true,
+	},
+	{
+		Artist:        allmusic.Artist{Name: "*NSYNC"},
+		NewestRelease: allmusic.Album{Title: "No Strings | Attached", Rating: 6},
+		Score:         60,
+	},
+}
+
+func Test_ExecuteMarkdownTemplate(t *testing.T) {
+	//when
+	out, err := NewMarkdownTemplate(markdownDiscographies).ExecuteMarkdownTemplate()
+
+	//then
+	require.NoError(t, err)
+	assert.Equal(t, `| # | Cover | Artist | Album | Genres | Rating | Score |
+|---|---|---|---|---|---|---|
+| 1 | ![cover](https://cdn.allmusic.com/cover.jpg) | [King Diamond](https://www.allmusic.com/artist/king-diamond-mn0000770007) (watchlist) | [Give Me Your Soul... Please](https://www.allmusic.com/album/give-me-your-soul-please-mw0000733393) | Heavy Metal, Black Metal | ★★★★½ | 90 |
+| 2 | ![cover](`+coverImageUrl("")+`) | \*NSYNC | No Strings \| Attached |  | ★★★☆☆ | 60 |
+`, out)
+}
+
+func Test_ExecuteMarkdownTemplate_List(t *testing.T) {
+	//when
+	out, err := NewMarkdownTemplate(markdownDiscographies).AsList().WithLimit(1).ExecuteMarkdownTemplate()
+
+	//then
+	require.NoError(t, err)
+	assert.Equal(t, `1. ![cover](https://cdn.allmusic.com/cover.jpg) **[King Diamond](https://www.allmusic.com/artist/king-diamond-mn0000770007) – [Give Me Your Soul... Please](https://www.allmusic.com/album/give-me-your-soul-please-mw0000733393)** (watchlist)
+   - Genres: Heavy Metal, Black Metal
+   - Rating: ★★★★½
+   - Score: 90 / 100
+`, out)
+}
+
+func Test_ExecuteMarkdownTemplate_WithoutCovers(t *testing.T) {
+	//when
+	table, err := NewMarkdownTemplate(markdownDiscographies[1:]).WithoutCovers().ExecuteMarkdownTemplate()
+	require.NoError(t, err)
+	list, err := NewMarkdownTemplate(markdownDiscographies[1:]).AsList().WithoutCovers().ExecuteMarkdownTemplate()
+	require.NoError(t, err)
+
+	//then
+	assert.Equal(t, `| # | Artist | Album | Genres | Rating | Score |
+|---|---|---|---|---|---|
+| 1 | \*NSYNC | No Strings \| Attached |  | ★★★☆☆ | 60 |
+`, table)
+	assert.Equal(t, `1. **\*NSYNC – No Strings \| Attached**
+   - Rating: ★★★☆☆
+   - Score: 60 / 100
+`, list)
+}
+
+func Test_ExecuteMarkdownTemplate_Empty(t *testing.T) {
+	for _, template := range []MarkdownTemplate{NewMarkdownTemplate(nil), NewMarkdownTemplate(nil).AsList()} {
+		out, err := template.ExecuteMarkdownTemplate()
+
+		require.NoError(t, err)
+		assert.Equal(t, "There are no interesting new releases.\n", out)
+	}
+}
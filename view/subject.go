@@ -0,0 +1,18 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/ynori7/music/allmusic"
+)
+
+func GetNewReleasesSubjectLine(releaseWeek allmusic.ReleaseWeek) string {
+	return fmt.Sprintf("Newest releases from the week of %s", releaseWeek.Format("2006-01-02"))
+}
+
+func GetBackfillSubjectLine(from, to allmusic.ReleaseWeek) string {
+	if from.Equal(to) {
+		return GetNewReleasesSubjectLine(from)
+	}
+	return fmt.Sprintf("Newest releases from the weeks of %s to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
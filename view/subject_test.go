@@ -0,0 +1,49 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+)
+
+func Test_getSubjectLine(t *testing.T) {
+	testcases := map[string]struct {
+		ReleaseWeek string
+		Expected    string
+	}{
+		"Release Friday": {
+			ReleaseWeek: "20200327",
+			Expected:    "Newest releases from the week of 2020-03-27",
+		},
+		"Date within the release week": {
+			ReleaseWeek: "20200331",
+			Expected:    "Newest releases from the week of 2020-03-27",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		week, err := allmusic.ParseReleaseWeek(testdata.ReleaseWeek)
+		require.NoError(t, err, testcase)
+
+		subject := GetNewReleasesSubjectLine(week)
+		assert.Equal(t, testdata.Expected, subject, testcase)
+	}
+}
+
+func Test_getBackfillSubjectLine(t *testing.T) {
+	//given
+	from, err := allmusic.ParseReleaseWeek("20200306")
+	require.NoError(t, err)
+	to, err := allmusic.ParseReleaseWeek("20200327")
+	require.NoError(t, err)
+
+	//when
+	subject := GetBackfillSubjectLine(from, to)
+
+	//then
+	assert.Equal(t, "Newest releases from the weeks of 2020-03-06 to 2020-03-27", subject)
+	assert.Equal(t, "Newest releases from the week of 2020-03-27", GetBackfillSubjectLine(to, to))
+	assert.Equal(t, "Newest releases from the week of 2020-03-27", GetBackfillSubjectLine(from.AddWeeks(3), to), "Computed weeks should be equal to parsed ones")
+}
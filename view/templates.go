@@ -0,0 +1,66 @@
+package view
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	texttemplate "text/template"
+
+	"github.com/ynori7/music/allmusic"
+)
+
+// The built-in templates are parsed once. They're covered by the tests, so they can't fail at runtime.
+var (
+	defaultHtmlTemplate = htmltemplate.Must(parseHtmlTemplate(htmlTemplate))
+	defaultTextTemplate = texttemplate.Must(parseTextTemplate(textTemplate))
+)
+
+// Templates are the parsed report templates. The zero value uses the built-in templates.
+type Templates struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// LoadTemplates parses the template files. The built-in template is used for an empty path.
+// The templates are executed once with sample data, so mistakes are reported before the run starts.
+func LoadTemplates(htmlFile, textFile string) (Templates, error) {
+	var templates Templates
+	sample := []allmusic.Discography{{}}
+
+	if htmlFile != "" {
+		data, err := os.ReadFile(htmlFile)
+		if err != nil {
+			return templates, fmt.Errorf("error reading html template: %w", err)
+		}
+		if templates.html, err = parseHtmlTemplate(string(data)); err != nil {
+			return templates, fmt.Errorf("invalid html template %s: %w", htmlFile, err)
+		}
+		if _, err := NewHtmlTemplate(sample).WithTemplates(templates).ExecuteHtmlTemplate(); err != nil {
+			return templates, fmt.Errorf("invalid html template %s: %w", htmlFile, err)
+		}
+	}
+
+	if textFile != "" {
+		data, err := os.ReadFile(textFile)
+		if err != nil {
+			return templates, fmt.Errorf("error reading text template: %w", err)
+		}
+		if templates.text, err = parseTextTemplate(string(data)); err != nil {
+			return templates, fmt.Errorf("invalid text template %s: %w", textFile, err)
+		}
+		if _, err := NewTextTemplate(sample).WithTemplates(templates).ExecuteTextTemplate(); err != nil {
+			return templates, fmt.Errorf("invalid text template %s: %w", textFile, err)
+		}
+	}
+
+	return templates, nil
+}
+
+// parseHtmlTemplate parses the template with the helper funcs. They're replaced for each execution.
+func parseHtmlTemplate(text string) (*htmltemplate.Template, error) {
+	return htmltemplate.New("html").Funcs(HtmlTemplate{}.funcs()).Parse(text)
+}
+
+func parseTextTemplate(text string) (*texttemplate.Template, error) {
+	return texttemplate.New("text").Funcs(textFuncs).Parse(text)
+}
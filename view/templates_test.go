@@ -0,0 +1,94 @@
+package view
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+)
+
+func Test_LoadTemplates(t *testing.T) {
+	//given
+	dir := t.TempDir()
+	htmlFile := filepath.Join(dir, "report.html")
+	textFile := filepath.Join(dir, "report.txt")
+	require.NoError(t, os.WriteFile(htmlFile, []byte(`{{range $i, $d := .Discographies}}<img src="{{coverImage $d.NewestRelease.Image}}"><img src="{{allmusicRating $d.NewestRelease.Rating}}">{{if mod $i 2}}even{{end}}{{end}}`), 0644))
+	require.NoError(t, os.WriteFile(textFile, []byte(`{{range .Discographies}}{{.Artist.Name}}: {{rating .NewestRelease.Rating}}{{end}}`), 0644))
+
+	discographies := []allmusic.Discography{{
+		Artist:        allmusic.Artist{Name: "Ghost"},
+		NewestRelease: allmusic.Album{Image: "https://cdn.allmusic.com/cover.jpg?f=2", Rating: 8},
+	}}
+
+	//when
+	templates, err := LoadTemplates(htmlFile, textFile)
+
+	//then
+	require.NoError(t, err)
+
+	html, err := NewHtmlTemplate(discographies).WithTemplates(templates).ExecuteHtmlTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, `<img src="`+coverImageUrl("https://cdn.allmusic.com/cover.jpg?f=2")+`"><img src="`+ratingImageUrl(8)+`">even`, html)
+
+	text, err := NewTextTemplate(discographies).WithTemplates(templates).ExecuteTextTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, "Ghost: 4 / 5 stars", text)
+}
+
+func Test_LoadTemplates_Default(t *testing.T) {
+	//when
+	templates, err := LoadTemplates("", "")
+
+	//then
+	require.NoError(t, err)
+
+	expected, err := NewTextTemplate(nil).ExecuteTextTemplate()
+	require.NoError(t, err)
+	actual, err := NewTextTemplate(nil).WithTemplates(templates).ExecuteTextTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func Test_LoadTemplates_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		return file
+	}
+
+	testcases := map[string]struct {
+		htmlFile string
+		textFile string
+		expected string
+	}{
+		"missing file": {
+			htmlFile: filepath.Join(dir, "missing.html"),
+			expected: "error reading html template",
+		},
+		"html syntax error": {
+			htmlFile: writeTemplate("syntax.html", `{{range .Discographies}}`),
+			expected: "invalid html template",
+		},
+		"unknown func": {
+			textFile: writeTemplate("func.txt", `{{stars .}}`),
+			expected: "invalid text template",
+		},
+		"unknown field": {
+			textFile: writeTemplate("field.txt", `{{range .Discographies}}{{.Artist.Label}}{{end}}`),
+			expected: "invalid text template",
+		},
+	}
+
+	for testcase, testdata := range testcases {
+		//when
+		_, err := LoadTemplates(testdata.htmlFile, testdata.textFile)
+
+		//then
+		require.Error(t, err, testcase)
+		assert.Contains(t, err.Error(), testdata.expected, testcase)
+	}
+}
@@ -0,0 +1,70 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ynori7/music/allmusic"
+)
+
+type TextTemplate struct {
+	Discographies []allmusic.Discography
+
+	template *template.Template
+}
+
+func NewTextTemplate(discographies []allmusic.Discography) TextTemplate {
+	return TextTemplate{
+		Discographies: discographies,
+		template:      defaultTextTemplate,
+	}
+}
+
+// WithTemplates uses the custom text template if there is one
+func (t TextTemplate) WithTemplates(templates Templates) TextTemplate {
+	if templates.text != nil {
+		t.template = templates.text
+	}
+	return t
+}
+
+func (t TextTemplate) ExecuteTextTemplate() (string, error) {
+	var b bytes.Buffer
+	if err := t.template.Execute(&b, t); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// textFuncs are the helpers which are available in the text templates, including the ones of the HTML templates
+var textFuncs = template.FuncMap{
+	"mod":            func(i, j int) bool { return i%j == 0 },
+	"allmusicRating": ratingImageUrl,
+	"coverImage":     coverImageUrl,
+	"inc":            func(i int) int { return i + 1 },
+	"join":           strings.Join,
+	"rating":         textRating,
+}
+
+// textRating converts the rating out of 10 to the stars which allmusic shows
+func textRating(r int) string {
+	if r == 0 {
+		return "not rated yet"
+	}
+	if r%2 == 0 {
+		return fmt.Sprintf("%d / 5 stars", r/2)
+	}
+	return fmt.Sprintf("%d.5 / 5 stars", r/2)
+}
+
+const textTemplate = `{{ range $i, $val := .Discographies }}{{ inc $i }}. {{ $val.Artist.Name }} - {{ $val.NewestRelease.Title }}{{ if $val.Watchlisted }} (watchlist){{ end }}
+{{ if $val.Artist.Genres }}   Genres: {{ join $val.Artist.Genres ", " }}
+{{ end }}   Rating: {{ rating $val.NewestRelease.Rating }}
+   Score: {{ $val.Score }} / 100
+{{ if $val.NewestRelease.Link }}   Album: {{ $val.NewestRelease.Link }}
+{{ end }}{{ if $val.Artist.Link }}   Artist: {{ $val.Artist.Link }}
+{{ end }}
+{{ else }}There are no interesting new releases.
+{{ end }}`
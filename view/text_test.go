@@ -0,0 +1,59 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ynori7/music/allmusic"
+)
+
+func Test_ExecuteTextTemplate(t *testing.T) {
+	//given
+	discographies := []allmusic.Discography{
+		{
+			Artist: allmusic.Artist{
+				Name:   "King Diamond",
+				Genres: []string{"Heavy Metal", "Black Metal"},
+				Link:   "https://www.allmusic.com/artist/king-diamond-mn0000770007",
+			},
+			NewestRelease: allmusic.Album{
+				Title:  "Give Me Your Soul... Please",
+				Link:   "https://www.allmusic.com/album/give-me-your-soul-please-mw0000733393",
+				Rating: 9,
+			},
+			Score:       90,
+			Watchlisted: true,
+		},
+		{
+			Artist:        allmusic.Artist{Name: "Ghost"},
+			NewestRelease: allmusic.Album{Title: "Impera"},
+			Score:         60,
+		},
+	}
+
+	//when
+	out, err := NewTextTemplate(discographies).ExecuteTextTemplate()
+
+	//then
+	require.NoError(t, err)
+	assert.Equal(t, `1. King Diamond - Give Me Your Soul... Please (watchlist)
+   Genres: Heavy Metal, Black Metal
+   Rating: 4.5 / 5 stars
+   Score: 90 / 100
+   Album: https://www.allmusic.com/album/give-me-your-soul-please-mw0000733393
+   Artist: https://www.allmusic.com/artist/king-diamond-mn0000770007
+
+2. Ghost - Impera
+   Rating: not rated yet
+   Score: 60 / 100
+
+`, out)
+}
+
+func Test_ExecuteTextTemplate_Empty(t *testing.T) {
+	out, err := NewTextTemplate(nil).ExecuteTextTemplate()
+
+	require.NoError(t, err)
+	assert.Equal(t, "There are no interesting new releases.\n", out)
+}